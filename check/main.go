@@ -2,15 +2,201 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/arbourd/concourse-slack-alert-resource/concourse"
 )
 
+const (
+	defaultPendingAfter = 30 * time.Minute
+	jobBuildsLimit      = 100
+)
+
+// now returns the current time. It is replaced in tests.
+var now = time.Now
+
+func check(input *concourse.CheckRequest) (concourse.CheckResponse, error) {
+	watch := input.Source.Watch
+	if watch == nil {
+		return concourse.CheckResponse{}, nil
+	}
+	if watch.Team == "" {
+		return nil, errors.New("watch team cannot be blank")
+	}
+
+	host := input.Source.ConcourseURL
+	if host == "" {
+		host = os.Getenv("ATC_EXTERNAL_URL")
+	}
+	host = strings.TrimSuffix(host, "/")
+
+	c, err := concourse.NewClient(host, watch.Team, input.Source.Username, input.Source.Password)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to Concourse: %w", err)
+	}
+
+	pipelines, err := c.Pipelines()
+	if err != nil {
+		return nil, fmt.Errorf("error requesting Concourse pipelines: %w", err)
+	}
+
+	versions := concourse.CheckResponse{}
+	for _, p := range pipelines {
+		if p.Archived || (len(watch.Pipelines) > 0 && !concourse.MatchAny(watch.Pipelines, p.Name)) || concourse.MatchAny(watch.Exclude, p.Name) {
+			continue
+		}
+
+		pipelineURL := fmt.Sprintf("%s/teams/%s/pipelines/%s", host, url.PathEscape(watch.Team), url.PathEscape(p.Name))
+		if p.Paused {
+			v := newVersion("paused_pipeline", watch.Team, p)
+			v["message"] = fmt.Sprintf("Pipeline %s is paused", v["pipeline"])
+			v["url"] = pipelineURL + p.InstanceVarsQuery()
+			if p.PausedAt > 0 {
+				v["paused_at"] = strconv.Itoa(p.PausedAt)
+			}
+			versions = append(versions, v)
+			continue
+		}
+
+		jobs, err := c.Jobs(p.Name, p.InstanceVars)
+		if err != nil {
+			return nil, fmt.Errorf("error requesting Concourse jobs: %w", err)
+		}
+
+		for _, j := range jobs {
+			if j.Paused || concourse.MatchAny(watch.Exclude, fmt.Sprintf("%s/%s", p.Name, j.Name)) {
+				continue
+			}
+			jobURL := fmt.Sprintf("%s/jobs/%s%s", pipelineURL, url.PathEscape(j.Name), p.InstanceVarsQuery())
+
+			if v, ok := pendingBuild(watch, p, j); ok {
+				v["url"] = fmt.Sprintf("%s/jobs/%s/builds/%s%s", pipelineURL, url.PathEscape(j.Name), url.PathEscape(j.NextBuild.Name), p.InstanceVarsQuery())
+				versions = append(versions, v)
+			}
+
+			if watch.StaleAfter == 0 || j.FinishedBuild == nil {
+				continue
+			}
+			v, ok, err := staleJob(c, watch, p, j)
+			if err != nil {
+				return nil, fmt.Errorf("error requesting Concourse builds: %w", err)
+			}
+			if ok {
+				v["url"] = jobURL
+				versions = append(versions, v)
+			}
+		}
+	}
+
+	return versions, nil
+}
+
+// newVersion returns a version identifying an issue of the pipeline.
+func newVersion(issue, team string, p concourse.Pipeline) concourse.Version {
+	v := concourse.Version{"type": issue, "team": team, "pipeline": p.Name}
+	if len(p.InstanceVars) > 0 {
+		vars, _ := json.Marshal(p.InstanceVars)
+		v["instance_vars"] = string(vars)
+		v["pipeline"] = fmt.Sprintf("%s/%s", p.Name, vars)
+	}
+	return v
+}
+
+// pendingBuild returns a version if the job's next build has been pending for
+// longer than the watch allows. Builds without a creation time are assumed to
+// have been pending since the previous build finished.
+func pendingBuild(watch *concourse.Watch, p concourse.Pipeline, j concourse.Job) (concourse.Version, bool) {
+	b := j.NextBuild
+	if b == nil || b.Status != "pending" {
+		return nil, false
+	}
+
+	since := b.CreateTime
+	if since == 0 && j.FinishedBuild != nil {
+		since = j.FinishedBuild.EndTime
+	}
+	if since == 0 {
+		return nil, false
+	}
+
+	after := time.Duration(watch.PendingAfter)
+	if after == 0 {
+		after = defaultPendingAfter
+	}
+	if now().Sub(time.Unix(int64(since), 0)) < after {
+		return nil, false
+	}
+
+	v := newVersion("pending_build", watch.Team, p)
+	v["job"] = j.Name
+	v["build"] = b.Name
+	v["message"] = fmt.Sprintf("Build %s/%s #%s is stuck pending", v["pipeline"], j.Name, b.Name)
+	return v, true
+}
+
+// staleJob returns a version if the job has not succeeded for longer than the
+// watch allows.
+func staleJob(c *concourse.Client, watch *concourse.Watch, p concourse.Pipeline, j concourse.Job) (concourse.Version, bool, error) {
+	last := j.FinishedBuild
+	if last.Status != "succeeded" {
+		builds, err := c.JobBuilds(p.Name, j.Name, p.InstanceVars, jobBuildsLimit)
+		if err != nil {
+			return nil, false, err
+		}
+
+		i := slices.IndexFunc(builds, func(b concourse.Build) bool { return b.Status == "succeeded" })
+		switch {
+		case i > -1:
+			last = &builds[i]
+		case len(builds) > 0:
+			// No success within the limit, so the oldest build is the
+			// latest known time the job could have succeeded.
+			last = &concourse.Build{EndTime: builds[len(builds)-1].EndTime}
+		default:
+			last = &concourse.Build{EndTime: last.EndTime}
+		}
+	}
+
+	if now().Sub(time.Unix(int64(last.EndTime), 0)) < time.Duration(watch.StaleAfter) {
+		return nil, false, nil
+	}
+
+	v := newVersion("stale_job", watch.Team, p)
+	v["job"] = j.Name
+	if last.Name != "" {
+		v["last_success"] = last.Name
+		v["message"] = fmt.Sprintf("Job %s/%s has not succeeded since build #%s", v["pipeline"], j.Name, last.Name)
+	} else {
+		v["last_success"] = "none"
+		v["message"] = fmt.Sprintf("Job %s/%s has no recent successful builds", v["pipeline"], j.Name)
+	}
+	return v, true, nil
+}
+
 func main() {
-	err := json.NewEncoder(os.Stdout).Encode(concourse.CheckResponse{})
+	var input *concourse.CheckRequest
+	err := json.NewDecoder(os.Stdin).Decode(&input)
+	if err != nil {
+		log.Fatalln(fmt.Errorf("error reading stdin: %w", err))
+	}
+	if input == nil {
+		input = &concourse.CheckRequest{}
+	}
+
+	versions, err := check(input)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = json.NewEncoder(os.Stdout).Encode(versions)
 	if err != nil {
 		log.Fatalln(fmt.Errorf("error: %s", err))
 	}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheck(t *testing.T) {
+	current := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	ago := func(d time.Duration) int { return int(current.Add(-d).Unix()) }
+
+	pipelines := []concourse.Pipeline{
+		{Name: "demo"},
+		{Name: "release", Paused: true, PausedAt: 1700000000},
+		{Name: "branch", Paused: true, InstanceVars: map[string]any{"branch": "main"}},
+		{Name: "old", Archived: true, Paused: true},
+		{Name: "sandbox", Paused: true},
+	}
+	jobs := []concourse.Job{
+		{Name: "unit", FinishedBuild: &concourse.Build{Name: "3", Status: "succeeded", EndTime: ago(time.Hour)}},
+		{Name: "nightly", FinishedBuild: &concourse.Build{Name: "9", Status: "failed", EndTime: ago(time.Hour)}},
+		{Name: "deploy", FinishedBuild: &concourse.Build{Name: "5", Status: "succeeded", EndTime: ago(time.Hour)}, NextBuild: &concourse.Build{Name: "6", Status: "pending"}},
+		{Name: "queued", NextBuild: &concourse.Build{Name: "1", Status: "pending", CreateTime: ago(time.Minute)}},
+		{Name: "paused", Paused: true, FinishedBuild: &concourse.Build{Name: "1", Status: "failed", EndTime: ago(100 * time.Hour)}},
+	}
+	nightly := []concourse.Build{
+		{Name: "9", Status: "failed", EndTime: ago(time.Hour)},
+		{Name: "8", Status: "succeeded", EndTime: ago(50 * time.Hour)},
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp any
+		switch r.URL.Path {
+		case "/api/v1/teams/main/pipelines":
+			resp = pipelines
+		case "/api/v1/teams/main/pipelines/demo/jobs":
+			resp = jobs
+		case "/api/v1/teams/main/pipelines/demo/jobs/nightly/builds":
+			resp = nightly
+		default:
+			http.Error(w, "", http.StatusNotFound)
+			return
+		}
+		b, _ := json.Marshal(resp)
+		w.Write(b)
+	}))
+	defer s.Close()
+
+	cases := map[string]struct {
+		input *concourse.CheckRequest
+		want  concourse.CheckResponse
+		err   bool
+	}{
+		"without watch": {
+			input: &concourse.CheckRequest{},
+			want:  concourse.CheckResponse{},
+		},
+		"watch": {
+			input: &concourse.CheckRequest{
+				Source: concourse.Source{
+					ConcourseURL: s.URL,
+					Watch: &concourse.Watch{
+						Team:       "main",
+						Exclude:    []string{"sandbox"},
+						StaleAfter: concourse.Duration(48 * time.Hour),
+					},
+				},
+			},
+			want: concourse.CheckResponse{
+				{
+					"type":         "stale_job",
+					"team":         "main",
+					"pipeline":     "demo",
+					"job":          "nightly",
+					"last_success": "8",
+					"message":      "Job demo/nightly has not succeeded since build #8",
+					"url":          s.URL + "/teams/main/pipelines/demo/jobs/nightly",
+				},
+				{
+					"type":     "pending_build",
+					"team":     "main",
+					"pipeline": "demo",
+					"job":      "deploy",
+					"build":    "6",
+					"message":  "Build demo/deploy #6 is stuck pending",
+					"url":      s.URL + "/teams/main/pipelines/demo/jobs/deploy/builds/6",
+				},
+				{
+					"type":      "paused_pipeline",
+					"team":      "main",
+					"pipeline":  "release",
+					"paused_at": "1700000000",
+					"message":   "Pipeline release is paused",
+					"url":       s.URL + "/teams/main/pipelines/release",
+				},
+				{
+					"type":          "paused_pipeline",
+					"team":          "main",
+					"pipeline":      `branch/{"branch":"main"}`,
+					"instance_vars": `{"branch":"main"}`,
+					"message":       `Pipeline branch/{"branch":"main"} is paused`,
+					"url":           s.URL + "/teams/main/pipelines/branch?vars=%7B%22branch%22%3A%22main%22%7D",
+				},
+			},
+		},
+		"error without team": {
+			input: &concourse.CheckRequest{
+				Source: concourse.Source{ConcourseURL: s.URL, Watch: &concourse.Watch{}},
+			},
+			err: true,
+		},
+		"error with unknown team": {
+			input: &concourse.CheckRequest{
+				Source: concourse.Source{ConcourseURL: s.URL, Watch: &concourse.Watch{Team: "other"}},
+			},
+			err: true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := check(c.input)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from check:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from check:\n\t(GOT): nil")
+			} else if !c.err && !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected concourse.CheckResponse value from check:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package concourse
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
@@ -17,21 +18,110 @@ type Build struct {
 	APIURL       string         `json:"api_url"`
 	Pipeline     string         `json:"pipeline_name"`
 	InstanceVars map[string]any `json:"pipeline_instance_vars,omitempty"`
+	CreateTime   int            `json:"create_time,omitempty"`
 	StartTime    int            `json:"start_time"`
 	EndTime      int            `json:"end_time"`
 }
 
+// A Pipeline is a pipeline's data from the undocumented Concourse API.
+type Pipeline struct {
+	ID           int            `json:"id"`
+	Name         string         `json:"name"`
+	Team         string         `json:"team_name"`
+	InstanceVars map[string]any `json:"instance_vars,omitempty"`
+	Paused       bool           `json:"paused,omitempty"`
+	PausedAt     int            `json:"paused_at,omitempty"`
+	Archived     bool           `json:"archived,omitempty"`
+	Public       bool           `json:"public,omitempty"`
+}
+
+// InstanceVarsQuery returns the query string that selects the pipeline's
+// instance in the web UI, or an empty string if the pipeline is not instanced.
+func (p Pipeline) InstanceVarsQuery() string {
+	return InstanceVarsQuery(p.InstanceVars)
+}
+
+// InstanceVarsQuery returns the query string that selects a pipeline instance
+// by its instance vars in the web UI, or an empty string without vars.
+func InstanceVarsQuery(vars map[string]any) string {
+	if len(vars) == 0 {
+		return ""
+	}
+
+	b, err := json.Marshal(vars)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("?vars=%s", url.QueryEscape(string(b)))
+}
+
+// A Job is a job's data from the undocumented Concourse API.
+type Job struct {
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	Team          string   `json:"team_name"`
+	Pipeline      string   `json:"pipeline_name"`
+	Paused        bool     `json:"paused,omitempty"`
+	FinishedBuild *Build   `json:"finished_build,omitempty"`
+	NextBuild     *Build   `json:"next_build,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
+}
+
+// BuildResources are the resource versions used by a build from the
+// undocumented Concourse API.
+type BuildResources struct {
+	Inputs  []BuildInput  `json:"inputs"`
+	Outputs []BuildOutput `json:"outputs"`
+}
+
+// A BuildInput is a resource version fetched by a build.
+type BuildInput struct {
+	Name            string  `json:"name"`
+	Resource        string  `json:"resource"`
+	Version         Version `json:"version"`
+	FirstOccurrence bool    `json:"first_occurrence"`
+}
+
+// A BuildOutput is a resource version produced by a build.
+type BuildOutput struct {
+	Name    string  `json:"name"`
+	Version Version `json:"version"`
+}
+
+// A ResourceVersion is a resource's version and its metadata from the
+// undocumented Concourse API.
+type ResourceVersion struct {
+	ID       int        `json:"id"`
+	Version  Version    `json:"version"`
+	Metadata []Metadata `json:"metadata"`
+	Enabled  bool       `json:"enabled"`
+}
+
+// A BuildKind is the kind of build running the resource.
+type BuildKind string
+
+// The kinds of builds. One-off builds are created with `fly execute` and have
+// no pipeline or job. Resource check builds have a pipeline but no job.
+const (
+	KindJob    BuildKind = "job"
+	KindOneOff BuildKind = "one-off"
+	KindCheck  BuildKind = "check"
+)
+
 // BuildMetadata is the current build's metadata exposed via the environment.
 // https://concourse-ci.org/implementing-resources.html#resource-metadata
 type BuildMetadata struct {
+	Kind         BuildKind
 	Host         string
 	ID           string
 	TeamName     string
 	PipelineName string
-	InstanceVars string
+	InstanceVars map[string]any
 	JobName      string
 	BuildName    string
 	URL          string
+	PipelineURL  string
+	JobURL       string
 }
 
 // NewBuildMetadata returns a populated BuildMetadata.
@@ -48,24 +138,56 @@ func NewBuildMetadata(atcurl string) BuildMetadata {
 		PipelineName: os.Getenv("BUILD_PIPELINE_NAME"),
 		JobName:      os.Getenv("BUILD_JOB_NAME"),
 		BuildName:    os.Getenv("BUILD_NAME"),
-		InstanceVars: os.Getenv("BUILD_PIPELINE_INSTANCE_VARS"),
 	}
 
+	// The URL keeps the instance vars as they were set, even if they cannot
+	// be parsed.
+	instanceVars := os.Getenv("BUILD_PIPELINE_INSTANCE_VARS")
 	instanceVarsQuery := ""
-	if metadata.InstanceVars != "" {
-		instanceVarsQuery = fmt.Sprintf("?vars=%s", url.QueryEscape(metadata.InstanceVars))
+	if instanceVars != "" {
+		instanceVarsQuery = fmt.Sprintf("?vars=%s", url.QueryEscape(instanceVars))
+		json.Unmarshal([]byte(instanceVars), &metadata.InstanceVars)
+	}
+
+	switch {
+	case metadata.JobName != "":
+		metadata.Kind = KindJob
+	case metadata.PipelineName != "":
+		metadata.Kind = KindCheck
+	default:
+		metadata.Kind = KindOneOff
+	}
+
+	// "$HOST/teams/$BUILD_TEAM_NAME/pipelines/$BUILD_PIPELINE_NAME?vars=$BUILD_PIPELINE_INSTANCE_VARS"
+	if metadata.PipelineName != "" {
+		metadata.PipelineURL = fmt.Sprintf(
+			"%s/teams/%s/pipelines/%s%s",
+			metadata.Host,
+			url.PathEscape(metadata.TeamName),
+			url.PathEscape(metadata.PipelineName),
+			instanceVarsQuery,
+		)
+	}
+
+	// Builds without a job can only be linked to by their ID.
+	// "$HOST/builds/$BUILD_ID"
+	if metadata.Kind != KindJob {
+		metadata.URL = fmt.Sprintf("%s/builds/%s", metadata.Host, url.PathEscape(metadata.ID))
+		return metadata
 	}
 
-	// "$HOST/teams/$BUILD_TEAM_NAME/pipelines/$BUILD_PIPELINE_NAME/jobs/$BUILD_JOB_NAME/builds/$BUILD_NAME?var=$BUILD_PIPELINE_INSTANCE_VARS"
-	metadata.URL = fmt.Sprintf(
-		"%s/teams/%s/pipelines/%s/jobs/%s/builds/%s%s",
+	// "$HOST/teams/$BUILD_TEAM_NAME/pipelines/$BUILD_PIPELINE_NAME/jobs/$BUILD_JOB_NAME?vars=$BUILD_PIPELINE_INSTANCE_VARS"
+	jobPath := fmt.Sprintf(
+		"%s/teams/%s/pipelines/%s/jobs/%s",
 		metadata.Host,
 		url.PathEscape(metadata.TeamName),
 		url.PathEscape(metadata.PipelineName),
 		url.PathEscape(metadata.JobName),
-		url.PathEscape(metadata.BuildName),
-		instanceVarsQuery,
 	)
+	metadata.JobURL = jobPath + instanceVarsQuery
+
+	// "$HOST/teams/$BUILD_TEAM_NAME/pipelines/$BUILD_PIPELINE_NAME/jobs/$BUILD_JOB_NAME/builds/$BUILD_NAME?vars=$BUILD_PIPELINE_INSTANCE_VARS"
+	metadata.URL = fmt.Sprintf("%s/builds/%s%s", jobPath, url.PathEscape(metadata.BuildName), instanceVarsQuery)
 
 	return metadata
 }
@@ -19,41 +19,73 @@ func TestNewBuildMetadata(t *testing.T) {
 	cases := map[string]struct {
 		host         string
 		instanceVars string
+		env          map[string]string
 		want         BuildMetadata
 	}{
 		"environment only": {
 			want: BuildMetadata{
+				Kind:         KindJob,
 				Host:         "https://ci.example.com",
 				TeamName:     "main",
 				PipelineName: "demo",
-				InstanceVars: "",
 				JobName:      "my test",
 				BuildName:    "1",
 				URL:          "https://ci.example.com/teams/main/pipelines/demo/jobs/my%20test/builds/1",
+				PipelineURL:  "https://ci.example.com/teams/main/pipelines/demo",
+				JobURL:       "https://ci.example.com/teams/main/pipelines/demo/jobs/my%20test",
 			},
 		},
 		"url override": {
 			host: "https://example.com",
 			want: BuildMetadata{
+				Kind:         KindJob,
 				Host:         "https://example.com",
 				TeamName:     "main",
 				PipelineName: "demo",
-				InstanceVars: "",
 				JobName:      "my test",
 				BuildName:    "1",
 				URL:          "https://example.com/teams/main/pipelines/demo/jobs/my%20test/builds/1",
+				PipelineURL:  "https://example.com/teams/main/pipelines/demo",
+				JobURL:       "https://example.com/teams/main/pipelines/demo/jobs/my%20test",
 			},
 		},
 		"url with instance vars": {
 			instanceVars: `{"image_name":"my-image","pr_number":1234,"args":["start"]}`,
 			want: BuildMetadata{
+				Kind:         KindJob,
 				Host:         "https://ci.example.com",
 				TeamName:     "main",
 				PipelineName: "demo",
-				InstanceVars: `{"image_name":"my-image","pr_number":1234,"args":["start"]}`,
+				InstanceVars: map[string]any{"image_name": "my-image", "pr_number": float64(1234), "args": []any{"start"}},
 				JobName:      "my test",
 				BuildName:    "1",
 				URL:          `https://ci.example.com/teams/main/pipelines/demo/jobs/my%20test/builds/1?vars=%7B%22image_name%22%3A%22my-image%22%2C%22pr_number%22%3A1234%2C%22args%22%3A%5B%22start%22%5D%7D`,
+				PipelineURL:  `https://ci.example.com/teams/main/pipelines/demo?vars=%7B%22image_name%22%3A%22my-image%22%2C%22pr_number%22%3A1234%2C%22args%22%3A%5B%22start%22%5D%7D`,
+				JobURL:       `https://ci.example.com/teams/main/pipelines/demo/jobs/my%20test?vars=%7B%22image_name%22%3A%22my-image%22%2C%22pr_number%22%3A1234%2C%22args%22%3A%5B%22start%22%5D%7D`,
+			},
+		},
+		"one-off build": {
+			env: map[string]string{"BUILD_ID": "123", "BUILD_PIPELINE_NAME": "", "BUILD_JOB_NAME": "", "BUILD_NAME": "45"},
+			want: BuildMetadata{
+				Kind:      KindOneOff,
+				Host:      "https://ci.example.com",
+				ID:        "123",
+				TeamName:  "main",
+				BuildName: "45",
+				URL:       "https://ci.example.com/builds/123",
+			},
+		},
+		"resource check build": {
+			env: map[string]string{"BUILD_ID": "123", "BUILD_JOB_NAME": "", "BUILD_NAME": "check"},
+			want: BuildMetadata{
+				Kind:         KindCheck,
+				Host:         "https://ci.example.com",
+				ID:           "123",
+				TeamName:     "main",
+				PipelineName: "demo",
+				BuildName:    "check",
+				URL:          "https://ci.example.com/builds/123",
+				PipelineURL:  "https://ci.example.com/teams/main/pipelines/demo",
 			},
 		},
 	}
@@ -63,6 +95,10 @@ func TestNewBuildMetadata(t *testing.T) {
 			for k, v := range env {
 				os.Setenv(k, v)
 			}
+			os.Unsetenv("BUILD_ID")
+			for k, v := range c.env {
+				os.Setenv(k, v)
+			}
 			if c.instanceVars != "" {
 				os.Setenv("BUILD_PIPELINE_INSTANCE_VARS", c.instanceVars)
 			} else {
@@ -1,6 +1,7 @@
 package concourse
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,7 +9,10 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"golang.org/x/oauth2"
@@ -224,28 +228,192 @@ func (c *Client) loginLegacy(url, username, password string) error {
 	return nil
 }
 
+// varKeyRegexp matches instance var keys that do not need to be quoted.
+var varKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// addInstanceVars adds the instance vars to the query as `vars.` params with
+// JSON values. Nested vars use dotted paths, like `vars.a.b`.
+func addInstanceVars(q url.Values, vars map[string]any) {
+	var add func(path string, v any)
+	add = func(path string, v any) {
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			for k, child := range m {
+				add(path+"."+quoteVarKey(k), child)
+			}
+			return
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		q.Add(path, string(b))
+	}
+
+	for k, v := range vars {
+		add("vars."+quoteVarKey(k), v)
+	}
+}
+
+// quoteVarKey quotes an instance var key if it contains special characters.
+func quoteVarKey(k string) string {
+	if varKeyRegexp.MatchString(k) {
+		return k
+	}
+	return strconv.Quote(k)
+}
+
+// pipelineURL returns the API URL of a path under the pipeline. The pipeline
+// instance is selected by its instance vars.
+func (c *Client) pipelineURL(pipeline string, instanceVars map[string]any, path string, q url.Values) string {
+	if q == nil {
+		q = url.Values{}
+	}
+	addInstanceVars(q, instanceVars)
+
+	u := fmt.Sprintf("%s/api/v1/teams/%s/pipelines/%s%s", c.atcurl, url.PathEscape(c.team), url.PathEscape(pipeline), path)
+	if len(q) > 0 {
+		u = fmt.Sprintf("%s?%s", u, q.Encode())
+	}
+	return u
+}
+
+// getJSON requests the URL and decodes its JSON response into v.
+func (c *Client) getJSON(u string, v any) error {
+	r, err := c.conn.Get(u)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != 200 {
+		return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+	}
+
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
 // JobBuild finds and returns a Build from the Concourse API by its
 // pipeline name, job name and build name.
-func (c *Client) JobBuild(pipeline, job, name, instanceVars string) (*Build, error) {
-	u := fmt.Sprintf(
-		"%s/api/v1/teams/%s/pipelines/%s/jobs/%s/builds/%s%s",
-		c.atcurl,
-		c.team,
-		pipeline,
-		job,
-		name,
-		instanceVars,
-	)
+func (c *Client) JobBuild(pipeline, job, name string, instanceVars map[string]any) (*Build, error) {
+	u := c.pipelineURL(pipeline, instanceVars, fmt.Sprintf("/jobs/%s/builds/%s", url.PathEscape(job), url.PathEscape(name)), nil)
 
-	r, err := c.conn.Get(u)
+	var build *Build
+	err := c.getJSON(u, &build)
+	return build, err
+}
+
+// JobBuilds returns the latest Builds of a job, newest first.
+func (c *Client) JobBuilds(pipeline, job string, instanceVars map[string]any, limit int) ([]Build, error) {
+	q := url.Values{"limit": {strconv.Itoa(limit)}}
+	u := c.pipelineURL(pipeline, instanceVars, fmt.Sprintf("/jobs/%s/builds", url.PathEscape(job)), q)
+
+	var builds []Build
+	err := c.getJSON(u, &builds)
+	return builds, err
+}
+
+// Jobs returns the Jobs of a pipeline by its name.
+func (c *Client) Jobs(pipeline string, instanceVars map[string]any) ([]Job, error) {
+	u := c.pipelineURL(pipeline, instanceVars, "/jobs", nil)
+
+	var jobs []Job
+	err := c.getJSON(u, &jobs)
+	return jobs, err
+}
+
+// Pipelines returns the Pipelines of the Client's team.
+func (c *Client) Pipelines() ([]Pipeline, error) {
+	u := fmt.Sprintf("%s/api/v1/teams/%s/pipelines", c.atcurl, url.PathEscape(c.team))
+
+	var pipelines []Pipeline
+	err := c.getJSON(u, &pipelines)
+	return pipelines, err
+}
+
+// ResourceVersion finds and returns a ResourceVersion, including its metadata,
+// from the Concourse API by its pipeline name, resource name and version.
+func (c *Client) ResourceVersion(pipeline, resource string, version Version, instanceVars map[string]any) (*ResourceVersion, error) {
+	q := url.Values{}
+	for k, v := range version {
+		q.Add("filter", fmt.Sprintf("%s:%s", k, v))
+	}
+	u := c.pipelineURL(pipeline, instanceVars, fmt.Sprintf("/resources/%s/versions", url.PathEscape(resource)), q)
+
+	var versions []ResourceVersion
+	if err := c.getJSON(u, &versions); err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("resource version not found")
+	}
+	return &versions[0], nil
+}
+
+// BuildPlan returns the Plan of a build by its ID.
+func (c *Client) BuildPlan(id string) (*Plan, error) {
+	u := fmt.Sprintf("%s/api/v1/builds/%s/plan", c.atcurl, url.PathEscape(id))
+
+	var plan *Plan
+	err := c.getJSON(u, &plan)
+	return plan, err
+}
+
+// BuildResources returns the resource versions used by a build by its ID.
+func (c *Client) BuildResources(id string) (*BuildResources, error) {
+	u := fmt.Sprintf("%s/api/v1/builds/%s/resources", c.atcurl, url.PathEscape(id))
+
+	var resources *BuildResources
+	err := c.getJSON(u, &resources)
+	return resources, err
+}
+
+// BuildEvents returns the Events of a build by its ID. The events of a
+// running build are streamed until the wait time elapses.
+func (c *Client) BuildEvents(id string, wait time.Duration) ([]Event, error) {
+	u := fmt.Sprintf("%s/api/v1/builds/%s/events", c.atcurl, url.PathEscape(id))
+
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	r, err := c.conn.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
 	if r.StatusCode != 200 {
 		return nil, fmt.Errorf("unexpected status code: %d", r.StatusCode)
 	}
 
-	var build *Build
-	json.NewDecoder(r.Body).Decode(&build)
-	return build, nil
+	var events []Event
+	s := bufio.NewScanner(r.Body)
+	s.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		if line == "event: end" {
+			return events, nil
+		}
+
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+
+		var e Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return events, err
+		}
+		events = append(events, e)
+	}
+
+	// The stream of a running build stays open until the wait time elapses.
+	if err := s.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return events, err
+	}
+	return events, nil
 }
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -147,6 +148,7 @@ func TestNewClient(t *testing.T) {
 func TestJobBuild(t *testing.T) {
 	cases := map[string]struct {
 		build *Build
+		query url.Values
 		err   bool
 	}{
 		"basic": {build: &Build{
@@ -172,6 +174,10 @@ func TestJobBuild(t *testing.T) {
 				"pr_number": float64(1234),
 				"args":      []any{"start"},
 			},
+		}, query: url.Values{
+			"vars.image_name": {`"my-image"`},
+			"vars.pr_number":  {"1234"},
+			"vars.args":       {`["start"]`},
 		}},
 		"unauthorized": {
 			build: &Build{},
@@ -184,6 +190,9 @@ func TestJobBuild(t *testing.T) {
 			if c.err {
 				http.Error(w, "", http.StatusUnauthorized)
 			}
+			if q := r.URL.Query(); len(q) > 0 && !cmp.Equal(q, c.query) {
+				http.Error(w, "", http.StatusNotFound)
+			}
 			resp, _ := json.Marshal(c.build)
 			w.Write(resp)
 		}))
@@ -191,14 +200,8 @@ func TestJobBuild(t *testing.T) {
 
 		t.Run(name, func(t *testing.T) {
 			client := &Client{atcurl: u, team: c.build.Team, conn: &http.Client{}}
-			instanceVarsQuery := ""
-
-			if c.build.InstanceVars != nil {
-				varsBytes, _ := json.Marshal(c.build.InstanceVars)
-				instanceVarsQuery = fmt.Sprintf("?vars=%s", url.QueryEscape(string(varsBytes)))
-			}
 
-			build, err := client.JobBuild(c.build.Pipeline, c.build.Job, c.build.Name, instanceVarsQuery)
+			build, err := client.JobBuild(c.build.Pipeline, c.build.Job, c.build.Name, c.build.InstanceVars)
 
 			if err != nil && !c.err {
 				t.Fatalf("unexpected error from JobBuild:\n\t(ERR): %s", err)
@@ -211,3 +214,262 @@ func TestJobBuild(t *testing.T) {
 		s.Close()
 	}
 }
+
+func TestBuildPlan(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/builds/42/plan" {
+			http.Error(w, "", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"schema":"exec.v2","plan":{"id":"1","do":[{"id":"2","get":{"name":"repo"}},{"id":"3","task":{"name":"unit"}}]}}`))
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+	client := &Client{atcurl: u, team: "main", conn: &http.Client{}}
+
+	plan, err := client.BuildPlan("42")
+	if err != nil {
+		t.Fatalf("unexpected error from BuildPlan:\n\t(ERR): %s", err)
+	}
+
+	want := map[string]string{"2": "repo", "3": "unit"}
+	if got := plan.StepNames(); !cmp.Equal(got, want) {
+		t.Fatalf("unexpected step names from BuildPlan:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, want, cmp.Diff(got, want))
+	}
+
+	if _, err := client.BuildPlan("43"); err == nil {
+		t.Fatalf("expected an error from BuildPlan:\n\t(GOT): nil")
+	}
+}
+
+func TestBuildEvents(t *testing.T) {
+	exitStatus := 1
+
+	cases := map[string]struct {
+		end  bool
+		want []Event
+	}{
+		"finished build": {
+			end: true,
+			want: []Event{
+				{Event: "log", Version: "5.1", Data: EventData{Origin: EventOrigin{ID: "3", Source: "stdout"}, Payload: "FAIL\n"}},
+				{Event: "finish-task", Version: "4.0", Data: EventData{Origin: EventOrigin{ID: "3"}, ExitStatus: &exitStatus}},
+			},
+		},
+		"running build": {
+			want: []Event{
+				{Event: "log", Version: "5.1", Data: EventData{Origin: EventOrigin{ID: "3", Source: "stdout"}, Payload: "FAIL\n"}},
+				{Event: "finish-task", Version: "4.0", Data: EventData{Origin: EventOrigin{ID: "3"}, ExitStatus: &exitStatus}},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, "id: 0\nevent: event\ndata: {\"data\":{\"origin\":{\"id\":\"3\",\"source\":\"stdout\"},\"payload\":\"FAIL\\n\"},\"event\":\"log\",\"version\":\"5.1\"}\n\n")
+				fmt.Fprint(w, "id: 1\nevent: event\ndata: {\"data\":{\"origin\":{\"id\":\"3\"},\"exit_status\":1},\"event\":\"finish-task\",\"version\":\"4.0\"}\n\n")
+				if c.end {
+					fmt.Fprint(w, "event: end\ndata:\n\n")
+					return
+				}
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			}))
+			defer s.Close()
+			u, _ := url.Parse(s.URL)
+			client := &Client{atcurl: u, team: "main", conn: &http.Client{}}
+
+			events, err := client.BuildEvents("42", 100*time.Millisecond)
+			if err != nil {
+				t.Fatalf("unexpected error from BuildEvents:\n\t(ERR): %s", err)
+			} else if !cmp.Equal(events, c.want) {
+				t.Fatalf("unexpected Events from BuildEvents:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", events, c.want, cmp.Diff(events, c.want))
+			}
+			if !events[1].Failed() {
+				t.Fatalf("expected finish-task event to have failed")
+			}
+		})
+	}
+}
+
+func TestBuildResources(t *testing.T) {
+	want := &BuildResources{
+		Inputs: []BuildInput{
+			{Name: "repo", Resource: "repo", Version: Version{"ref": "abc123"}, FirstOccurrence: true},
+		},
+		Outputs: []BuildOutput{
+			{Name: "image", Version: Version{"digest": "sha256:123"}},
+		},
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/builds/42/resources" {
+			http.Error(w, "", http.StatusNotFound)
+			return
+		}
+		resp, _ := json.Marshal(want)
+		w.Write(resp)
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+	client := &Client{atcurl: u, team: "main", conn: &http.Client{}}
+
+	got, err := client.BuildResources("42")
+	if err != nil {
+		t.Fatalf("unexpected error from BuildResources:\n\t(ERR): %s", err)
+	} else if !cmp.Equal(got, want) {
+		t.Fatalf("unexpected BuildResources from BuildResources:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, want, cmp.Diff(got, want))
+	}
+
+	if _, err := client.BuildResources("43"); err == nil {
+		t.Fatalf("expected an error from BuildResources:\n\t(GOT): nil")
+	}
+}
+
+func TestResourceVersion(t *testing.T) {
+	cases := map[string]struct {
+		instanceVars map[string]any
+		wantQuery    string
+		versions     []ResourceVersion
+		err          bool
+	}{
+		"basic": {
+			wantQuery: "filter=ref%3Aabc123",
+			versions: []ResourceVersion{
+				{ID: 1, Version: Version{"ref": "abc123"}, Metadata: []Metadata{{Name: "author", Value: "Jane"}}, Enabled: true},
+			},
+		},
+		"instance vars": {
+			instanceVars: map[string]any{"branch": "main"},
+			wantQuery:    "filter=ref%3Aabc123&vars.branch=%22main%22",
+			versions: []ResourceVersion{
+				{ID: 1, Version: Version{"ref": "abc123"}, Enabled: true},
+			},
+		},
+		"not found": {
+			wantQuery: "filter=ref%3Aabc123",
+			err:       true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/teams/main/pipelines/demo/resources/repo/versions" || r.URL.RawQuery != c.wantQuery {
+					http.Error(w, "", http.StatusNotFound)
+					return
+				}
+				resp, _ := json.Marshal(c.versions)
+				w.Write(resp)
+			}))
+			defer s.Close()
+			u, _ := url.Parse(s.URL)
+			client := &Client{atcurl: u, team: "main", conn: &http.Client{}}
+
+			got, err := client.ResourceVersion("demo", "repo", Version{"ref": "abc123"}, c.instanceVars)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from ResourceVersion:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from ResourceVersion:\n\t(GOT): nil")
+			} else if !c.err && !cmp.Equal(got, &c.versions[0]) {
+				t.Fatalf("unexpected ResourceVersion from ResourceVersion:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, &c.versions[0], cmp.Diff(got, &c.versions[0]))
+			}
+		})
+	}
+}
+
+func TestJobs(t *testing.T) {
+	want := []Job{
+		{ID: 1, Name: "test", Team: "main", Pipeline: "demo", FinishedBuild: &Build{ID: 2, Name: "3", Status: "failed"}},
+		{ID: 2, Name: "deploy", Team: "main", Pipeline: "demo", Paused: true},
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/teams/main/pipelines/demo/jobs" || r.URL.Query().Get("vars.branch") != `"main"` {
+			http.Error(w, "", http.StatusNotFound)
+			return
+		}
+		resp, _ := json.Marshal(want)
+		w.Write(resp)
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+	client := &Client{atcurl: u, team: "main", conn: &http.Client{}}
+
+	got, err := client.Jobs("demo", map[string]any{"branch": "main"})
+	if err != nil {
+		t.Fatalf("unexpected error from Jobs:\n\t(ERR): %s", err)
+	} else if !cmp.Equal(got, want) {
+		t.Fatalf("unexpected Jobs from Jobs:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, want, cmp.Diff(got, want))
+	}
+
+	if _, err := client.Jobs("missing", nil); err == nil {
+		t.Fatalf("expected an error from Jobs:\n\t(GOT): nil")
+	}
+}
+
+func TestPipelines(t *testing.T) {
+	want := []Pipeline{
+		{ID: 1, Name: "demo", Team: "main"},
+		{ID: 2, Name: "release", Team: "main", InstanceVars: map[string]any{"version": "1.x"}, Paused: true},
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/teams/main/pipelines" {
+			http.Error(w, "", http.StatusNotFound)
+			return
+		}
+		resp, _ := json.Marshal(want)
+		w.Write(resp)
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+
+	client := &Client{atcurl: u, team: "main", conn: &http.Client{}}
+	got, err := client.Pipelines()
+	if err != nil {
+		t.Fatalf("unexpected error from Pipelines:\n\t(ERR): %s", err)
+	} else if !cmp.Equal(got, want) {
+		t.Fatalf("unexpected Pipelines from Pipelines:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, want, cmp.Diff(got, want))
+	}
+
+	if q := got[1].InstanceVarsQuery(); q != "?vars=%7B%22version%22%3A%221.x%22%7D" {
+		t.Fatalf("unexpected query from InstanceVarsQuery:\n\t(GOT): %#v", q)
+	}
+
+	client.team = "other"
+	if _, err := client.Pipelines(); err == nil {
+		t.Fatalf("expected an error from Pipelines:\n\t(GOT): nil")
+	}
+}
+
+func TestAddInstanceVars(t *testing.T) {
+	cases := map[string]struct {
+		vars map[string]any
+		want url.Values
+	}{
+		"empty": {
+			want: url.Values{},
+		},
+		"values": {
+			vars: map[string]any{"branch": "main", "pr": float64(12), "draft": false},
+			want: url.Values{"vars.branch": {`"main"`}, "vars.pr": {"12"}, "vars.draft": {"false"}},
+		},
+		"nested": {
+			vars: map[string]any{"env": map[string]any{"region": "us-east-1", "my.key": "x"}},
+			want: url.Values{"vars.env.region": {`"us-east-1"`}, `vars.env."my.key"`: {`"x"`}},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := url.Values{}
+			addInstanceVars(got, c.vars)
+			if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected query from addInstanceVars:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
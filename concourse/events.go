@@ -0,0 +1,91 @@
+package concourse
+
+import (
+	"encoding/json"
+)
+
+// A Plan is a build's plan from the undocumented Concourse API.
+type Plan struct {
+	Schema string          `json:"schema"`
+	Plan   json.RawMessage `json:"plan"`
+}
+
+// stepTypes are the plan keys of steps that have a name.
+var stepTypes = []string{"task", "get", "put", "check", "set_pipeline", "load_var", "run"}
+
+// StepNames returns the names of the plan's steps by their plan ID.
+func (p Plan) StepNames() map[string]string {
+	names := map[string]string{}
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if id, ok := v["id"].(string); ok {
+				for _, t := range stepTypes {
+					step, ok := v[t].(map[string]any)
+					if !ok {
+						continue
+					}
+					if name, ok := step["name"].(string); ok {
+						names[id] = name
+					} else if message, ok := step["message"].(string); ok {
+						names[id] = message
+					}
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+
+	var plan any
+	if json.Unmarshal(p.Plan, &plan) == nil {
+		walk(plan)
+	}
+	return names
+}
+
+// An Event is a build event from the undocumented Concourse API.
+type Event struct {
+	Event   string    `json:"event"`
+	Version string    `json:"version"`
+	Data    EventData `json:"data"`
+}
+
+// EventData is the data of a build event. Only the fields used for alerts
+// are decoded.
+type EventData struct {
+	Time       int         `json:"time"`
+	Origin     EventOrigin `json:"origin"`
+	Payload    string      `json:"payload"`
+	Message    string      `json:"message"`
+	Status     string      `json:"status"`
+	ExitStatus *int        `json:"exit_status"`
+	Succeeded  *bool       `json:"succeeded"`
+}
+
+// EventOrigin is the step that emitted a build event.
+type EventOrigin struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+}
+
+// Failed returns true if the event reports a failed or errored step.
+func (e Event) Failed() bool {
+	switch e.Event {
+	case "error":
+		return e.Data.Origin.ID != ""
+	case "finish-task", "finish-get", "finish-put":
+		if e.Data.Succeeded != nil {
+			return !*e.Data.Succeeded
+		}
+		return e.Data.ExitStatus != nil && *e.Data.ExitStatus != 0
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package concourse
+
+import "path"
+
+// MatchAny returns true if the name matches any of the glob patterns, such as
+// the patterns of pipelines and jobs.
+func MatchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package concourse
+
+import "testing"
+
+func TestMatchAny(t *testing.T) {
+	cases := map[string]struct {
+		patterns []string
+		name     string
+		want     bool
+	}{
+		"no patterns": {name: "demo", want: false},
+		"match":       {patterns: []string{"release-*", "demo"}, name: "release-1", want: true},
+		"no match":    {patterns: []string{"release-*"}, name: "demo", want: false},
+		"match job":   {patterns: []string{"*/deploy"}, name: "demo/deploy", want: true},
+		"separator":   {patterns: []string{"*"}, name: "demo/deploy", want: false},
+		"bad pattern": {patterns: []string{"["}, name: "demo", want: false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := MatchAny(c.patterns, c.name); got != c.want {
+				t.Fatalf("unexpected value from MatchAny:\n\t(GOT): %#v\n\t(WNT): %#v", got, c.want)
+			}
+		})
+	}
+}
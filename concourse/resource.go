@@ -1,13 +1,105 @@
 package concourse
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // A Source is the resource's source configuration.
 type Source struct {
-	URL          string `json:"url"`
-	Username     string `json:"username"`
-	Password     string `json:"password"`
-	ConcourseURL string `json:"concourse_url"`
-	Channel      string `json:"channel"`
-	Disable      bool   `json:"disable"`
+	URL              string               `json:"url"`
+	Token            string               `json:"token"`
+	Username         string               `json:"username"`
+	Password         string               `json:"password"`
+	ConcourseURL     string               `json:"concourse_url"`
+	Channel          string               `json:"channel"`
+	Disable          bool                 `json:"disable"`
+	Retry            Retry                `json:"retry"`
+	FailOnError      *bool                `json:"fail_on_error"`
+	StrictFiles      bool                 `json:"strict_files"`
+	IconBaseURL      string               `json:"icon_base_url"`
+	Theme            string               `json:"theme"`
+	Watch            *Watch               `json:"watch"`
+	AlertTypes       map[string]AlertType `json:"alert_types"`
+	InputURLs        map[string]string    `json:"input_urls"`
+	DryRun           bool                 `json:"dry_run"`
+	RedactPatterns   []string             `json:"redact_patterns"`
+	Routes           []Route              `json:"routes"`
+	Locale           string               `json:"locale"`
+	TranslationsFile string               `json:"translations_file"`
+	Sender           Sender               `json:"sender"`
+}
+
+// Watch configures the check operation to emit a version for every unhealthy
+// pipeline, job or build of a team.
+type Watch struct {
+	Team         string   `json:"team"`
+	Pipelines    []string `json:"pipelines"`
+	Exclude      []string `json:"exclude"`
+	StaleAfter   Duration `json:"stale_after"`
+	PendingAfter Duration `json:"pending_after"`
+}
+
+// A Sender overrides the name and icon that messages are posted as.
+type Sender struct {
+	Username  string `json:"username"`
+	IconURL   string `json:"icon_url"`
+	IconEmoji string `json:"icon_emoji"`
+}
+
+// An AlertType defines a custom alert type, or overrides the defaults of a
+// built-in alert type.
+type AlertType struct {
+	Color    string   `json:"color"`
+	IconURL  string   `json:"icon_url"`
+	Message  string   `json:"message"`
+	Mentions []string `json:"mentions"`
+	Channel  string   `json:"channel"`
+}
+
+// A Route sets where and whether alerts are sent. A route matches an alert
+// if each of its conditions matches. Empty conditions match all alerts.
+type Route struct {
+	AlertTypes   []string          `json:"alert_types"`
+	Teams        []string          `json:"teams"`
+	Pipelines    []string          `json:"pipelines"`
+	Jobs         []string          `json:"jobs"`
+	InstanceVars map[string]string `json:"instance_vars"`
+	Channels     []string          `json:"channels"`
+	Mentions     []string          `json:"mentions"`
+	Disable      *bool             `json:"disable"`
+}
+
+// Retry is the retry policy used when sending messages to Slack.
+// Zero values are replaced by the defaults of the out operation.
+type Retry struct {
+	MaxElapsed      Duration `json:"max_elapsed"`
+	InitialInterval Duration `json:"initial_interval"`
+	MaxInterval     Duration `json:"max_interval"`
+	MaxAttempts     int      `json:"max_attempts"`
+}
+
+// Duration is a time.Duration that is configured as a string, like "30s".
+type Duration time.Duration
+
+// UnmarshalJSON parses a Duration from a JSON string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON encodes a Duration as a JSON string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
 }
 
 // Metadata are a key-value pair that must be included for in the in and out
@@ -20,9 +112,21 @@ type Metadata struct {
 // Version is the key-value pair that the resource is checking, getting or putting.
 type Version map[string]string
 
+// CheckRequest is the input for the check operation.
+type CheckRequest struct {
+	Source  Source  `json:"source"`
+	Version Version `json:"version"`
+}
+
 // CheckResponse is the output for the check operation.
 type CheckResponse []Version
 
+// InRequest is the input for the in operation.
+type InRequest struct {
+	Source  Source  `json:"source"`
+	Version Version `json:"version"`
+}
+
 // InResponse is the output for the in operation.
 type InResponse struct {
 	Version  Version    `json:"version"`
@@ -31,15 +135,53 @@ type InResponse struct {
 
 // OutParams are the parameters that can be configured for the out operation.
 type OutParams struct {
-	AlertType   string `json:"alert_type"`
-	Channel     string `json:"channel"`
-	ChannelFile string `json:"channel_file"`
-	Color       string `json:"color"`
-	Message     string `json:"message"`
-	MessageFile string `json:"message_file"`
-	Text        string `json:"text"`
-	TextFile    string `json:"text_file"`
-	Disable     bool   `json:"disable"`
+	AlertType           string        `json:"alert_type"`
+	Channel             string        `json:"channel"`
+	ChannelFile         string        `json:"channel_file"`
+	Color               string        `json:"color"`
+	Message             string        `json:"message"`
+	MessageFile         string        `json:"message_file"`
+	Mentions            []string      `json:"mentions"`
+	Text                string        `json:"text"`
+	TextFile            string        `json:"text_file"`
+	StrictFiles         bool          `json:"strict_files"`
+	ShowFailedStep      bool          `json:"show_failed_step"`
+	LogLines            int           `json:"log_lines"`
+	ShowInputs          bool          `json:"show_inputs"`
+	Digest              bool          `json:"digest"`
+	Report              *ReportParams `json:"report"`
+	AggregateKey        string        `json:"aggregate_key"`
+	DryRun              bool          `json:"dry_run"`
+	LongText            string        `json:"long_text"`
+	TextLimit           int           `json:"text_limit"`
+	TextFormat          string        `json:"text_format"`
+	Attachments         []string      `json:"attachments"`
+	AttachmentsIn       string        `json:"attachments_in"`
+	MaxAttachments      int           `json:"max_attachments"`
+	MaxAttachmentSize   int64         `json:"max_attachment_size"`
+	JUnitReport         []string      `json:"junit_report"`
+	MaxFailedTests      int           `json:"max_failed_tests"`
+	Username            string        `json:"username"`
+	IconURL             string        `json:"icon_url"`
+	IconEmoji           string        `json:"icon_emoji"`
+	PostAt              string        `json:"post_at"`
+	CancelScheduled     string        `json:"cancel_scheduled"`
+	CancelScheduledFile string        `json:"cancel_scheduled_file"`
+	DMUsers             []string      `json:"dm_users"`
+	DMUsersFile         string        `json:"dm_users_file"`
+	EphemeralUser       string        `json:"ephemeral_user"`
+	EphemeralUserFile   string        `json:"ephemeral_user_file"`
+	SkipChannel         bool          `json:"skip_channel"`
+	Disable             bool          `json:"disable"`
+}
+
+// ReportParams configures the team-wide status report of the out operation.
+type ReportParams struct {
+	Team        string   `json:"team"`
+	Pipelines   []string `json:"pipelines"`
+	Exclude     []string `json:"exclude"`
+	MaxAge      Duration `json:"max_age"`
+	LongRunning Duration `json:"long_running"`
 }
 
 // OutRequest is in the input for the out operation.
@@ -5,12 +5,68 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/arbourd/concourse-slack-alert-resource/concourse"
 )
 
+// in writes the version to the destination directory, as a version.json file
+// and as a file per field, so it can be used by the out operation's _file
+// params.
+func in(input *concourse.InRequest, dest string) (*concourse.InResponse, error) {
+	if len(input.Version) == 0 || input.Version["ver"] == "static" {
+		return &concourse.InResponse{Version: concourse.Version{"ver": "static"}}, nil
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(input.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dest, "version.json"), b, 0644); err != nil {
+		return nil, err
+	}
+
+	var metadata []concourse.Metadata
+	for k, v := range input.Version {
+		if err := os.WriteFile(filepath.Join(dest, filepath.Base(k)), []byte(v), 0644); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range []string{"type", "pipeline", "job", "build", "message"} {
+		if v, ok := input.Version[k]; ok {
+			metadata = append(metadata, concourse.Metadata{Name: k, Value: v})
+		}
+	}
+
+	return &concourse.InResponse{Version: input.Version, Metadata: metadata}, nil
+}
+
 func main() {
-	err := json.NewEncoder(os.Stdout).Encode(concourse.InResponse{Version: concourse.Version{"ver": "static"}})
+	// The first argument is the destination directory.
+	dest := ""
+	if len(os.Args) > 1 {
+		dest = os.Args[1]
+	}
+
+	var input *concourse.InRequest
+	err := json.NewDecoder(os.Stdin).Decode(&input)
+	if err != nil {
+		log.Fatalln(fmt.Errorf("error reading stdin: %w", err))
+	}
+	if input == nil {
+		input = &concourse.InRequest{}
+	}
+
+	o, err := in(input, dest)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = json.NewEncoder(os.Stdout).Encode(o)
 	if err != nil {
 		log.Fatalln(fmt.Errorf("error: %s", err))
 	}
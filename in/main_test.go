@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIn(t *testing.T) {
+	cases := map[string]struct {
+		input *concourse.InRequest
+		want  *concourse.InResponse
+		files map[string]string
+	}{
+		"static": {
+			input: &concourse.InRequest{Version: concourse.Version{"ver": "static"}},
+			want:  &concourse.InResponse{Version: concourse.Version{"ver": "static"}},
+		},
+		"empty": {
+			input: &concourse.InRequest{},
+			want:  &concourse.InResponse{Version: concourse.Version{"ver": "static"}},
+		},
+		"watch version": {
+			input: &concourse.InRequest{Version: concourse.Version{"type": "paused_pipeline", "pipeline": "demo", "message": "Pipeline demo is paused"}},
+			want: &concourse.InResponse{
+				Version: concourse.Version{"type": "paused_pipeline", "pipeline": "demo", "message": "Pipeline demo is paused"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "paused_pipeline"},
+					{Name: "pipeline", Value: "demo"},
+					{Name: "message", Value: "Pipeline demo is paused"},
+				},
+			},
+			files: map[string]string{
+				"message":      "Pipeline demo is paused",
+				"pipeline":     "demo",
+				"version.json": `{"message":"Pipeline demo is paused","pipeline":"demo","type":"paused_pipeline"}`,
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			dest := t.TempDir()
+
+			got, err := in(c.input, dest)
+			if err != nil {
+				t.Fatalf("unexpected error from in:\n\t(ERR): %s", err)
+			} else if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected concourse.InResponse value from in:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+
+			for file, want := range c.files {
+				b, err := os.ReadFile(filepath.Join(dest, file))
+				if err != nil {
+					t.Fatal(err)
+				} else if string(b) != want {
+					t.Fatalf("unexpected contents of %s:\n\t(GOT): %#v\n\t(WNT): %#v", file, string(b), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+const (
+	// aggregateEventType is the metadata event type of aggregated messages.
+	aggregateEventType = "concourse_slack_alert_aggregate"
+	// aggregateHistoryLimit is the number of recent messages of the channel
+	// searched for the aggregated message.
+	aggregateHistoryLimit = 100
+	// aggregateAttempts is the number of times the channel is read before
+	// giving up, when other puts update the aggregated message at the same time.
+	aggregateAttempts = 10
+)
+
+// aggregateMetadata returns the metadata identifying a part of the message
+// aggregated by key.
+func aggregateMetadata(key string) *slack.MessageMetadata {
+	return &slack.MessageMetadata{
+		EventType: aggregateEventType,
+		EventPayload: map[string]any{
+			"key":   key,
+			"parts": []string{rand.Text()},
+		},
+	}
+}
+
+// aggregateSettle is the time a part must stay in the aggregated message
+// before it is considered merged.
+var aggregateSettle = time.Second
+
+// aggregate adds the message to the earliest message of the channel with the
+// same aggregate key, or posts it if there is none. Later puts only edit the
+// aggregated message, so that it notifies the channel once. If puts run at
+// the same time and more than one posts the message, the later messages are
+// merged into the earliest and deleted. As Slack cannot update a message
+// conditionally, every update merges all parts that are still posted on
+// their own, and a put only returns once its part has settled in the message.
+// Aggregation is best-effort: an update from a put that read an older version
+// of the message can still arrive after the settle time, and drop a part.
+func aggregate(c *slack.Client, m *slack.Message) error {
+	key, parts := aggregateParts(m.Metadata)
+	id := parts[0]
+
+	channel, err := c.ChannelID(m.Channel)
+	if err != nil {
+		return fmt.Errorf("error finding channel: %w", err)
+	}
+
+	// ts is the timestamp of the message of this put, if it posted one.
+	ts := ""
+	settled := false
+	for range aggregateAttempts {
+		history, err := c.History(channel, "", aggregateHistoryLimit)
+		if err != nil {
+			return err
+		}
+
+		// History is ordered from newest to oldest.
+		var posted []slack.Message
+		for _, h := range slices.Backward(history) {
+			if k, _ := aggregateParts(h.Metadata); k == key {
+				posted = append(posted, h)
+			}
+		}
+		if len(posted) == 0 {
+			if ts != "" {
+				return nil
+			}
+			// Read the channel again after posting, in case other puts
+			// posted at the same time.
+			if _, ts, err = c.PostMessage(m); err != nil {
+				return err
+			}
+			continue
+		}
+		if posted[0].TS == ts {
+			return nil
+		}
+
+		first := posted[0]
+		_, merged := aggregateParts(first.Metadata)
+		if slices.Contains(merged, id) {
+			if !settled {
+				settled = true
+				time.Sleep(aggregateSettle)
+				continue
+			}
+			if ts != "" {
+				return c.DeleteMessage(channel, ts)
+			}
+			return nil
+		}
+		settled = false
+
+		u := first
+		u.Attachments = slices.Clip(first.Attachments)
+		add := func(p slack.Message, part string) {
+			merged = append(merged, part)
+			u.Attachments = append(u.Attachments, p.Attachments...)
+			if p.Text != "" && !strings.Contains(u.Text, p.Text) {
+				u.Text = strings.TrimSpace(u.Text + " " + p.Text)
+			}
+		}
+		for _, p := range posted[1:] {
+			_, pparts := aggregateParts(p.Metadata)
+			if len(pparts) > 0 && !slices.Contains(merged, pparts[0]) {
+				add(p, pparts[0])
+			}
+		}
+		if ts == "" {
+			add(*m, id)
+		}
+		u.Metadata = &slack.MessageMetadata{
+			EventType: aggregateEventType,
+			EventPayload: map[string]any{
+				"key":   key,
+				"parts": merged,
+			},
+		}
+		if err := c.UpdateMessage(channel, first.TS, &u); err != nil {
+			return err
+		}
+	}
+	return errors.New("error aggregating slack message: message updated too many times")
+}
+
+// aggregateParts returns the aggregate key and the parts of the message
+// metadata. The key is empty if the message is not aggregated.
+func aggregateParts(md *slack.MessageMetadata) (string, []string) {
+	if md == nil || md.EventType != aggregateEventType {
+		return "", nil
+	}
+
+	key, _ := md.EventPayload["key"].(string)
+	var parts []string
+	switch p := md.EventPayload["parts"].(type) {
+	case []string:
+		parts = p
+	case []any:
+		for _, v := range p {
+			if s, ok := v.(string); ok {
+				parts = append(parts, s)
+			}
+		}
+	}
+	return key, parts
+}
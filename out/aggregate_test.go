@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeSlack is an in-memory channel of the Slack Web API.
+type fakeSlack struct {
+	mu       sync.Mutex
+	messages []slack.Message
+	next     int
+	posts    int
+}
+
+func (f *fakeSlack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var m slack.Message
+	if r.Method == http.MethodPost {
+		json.NewDecoder(r.Body).Decode(&m)
+	}
+
+	resp := map[string]any{"ok": true}
+	switch r.URL.Path {
+	case "/chat.postMessage":
+		f.next++
+		f.posts++
+		m.TS = fmt.Sprintf("%d.000000", f.next)
+		f.messages = append(f.messages, m)
+		resp["channel"] = "C123"
+		resp["ts"] = m.TS
+	case "/chat.update":
+		i := slices.IndexFunc(f.messages, func(h slack.Message) bool { return h.TS == m.TS })
+		f.messages[i] = m
+	case "/chat.delete":
+		f.messages = slices.DeleteFunc(f.messages, func(h slack.Message) bool { return h.TS == m.TS })
+	case "/conversations.list":
+		resp["channels"] = []map[string]string{{"id": "C123", "name": "concourse"}}
+	case "/conversations.history":
+		history := slices.Clone(f.messages)
+		slices.Reverse(history)
+		resp["messages"] = history
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestAggregate(t *testing.T) {
+	cases := map[string]struct {
+		puts       []string
+		other      []string
+		want       [][]string
+		posts      int
+		concurrent bool
+	}{
+		"single put": {
+			puts:  []string{"linux"},
+			want:  [][]string{{"linux"}},
+			posts: 1,
+		},
+		"across step": {
+			puts:  []string{"linux", "darwin", "windows"},
+			want:  [][]string{{"linux", "darwin", "windows"}},
+			posts: 1,
+		},
+		"different keys": {
+			puts:  []string{"linux", "darwin"},
+			other: []string{"arm64"},
+			want:  [][]string{{"linux", "darwin"}, {"arm64"}},
+			posts: 2,
+		},
+		"in parallel": {
+			puts:       []string{"linux", "darwin", "windows", "freebsd"},
+			want:       [][]string{{"linux", "darwin", "windows", "freebsd"}},
+			concurrent: true,
+		},
+	}
+
+	settle := aggregateSettle
+	aggregateSettle = 10 * time.Millisecond
+	t.Cleanup(func() { aggregateSettle = settle })
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := &fakeSlack{}
+			s := httptest.NewServer(f)
+			defer s.Close()
+
+			url := slack.APIURL
+			slack.APIURL = s.URL
+			defer func() { slack.APIURL = url }()
+
+			client := slack.NewClient("xoxb-token", slack.Retry{MaxElapsedTime: time.Second})
+			put := func(key, text string) error {
+				m := &slack.Message{
+					Channel:     "concourse",
+					Attachments: []slack.Attachment{{Fallback: text}},
+					Metadata:    aggregateMetadata(key),
+				}
+				return aggregate(client, m)
+			}
+
+			var wg sync.WaitGroup
+			for _, p := range c.puts {
+				if c.concurrent {
+					wg.Go(func() {
+						if err := put("1234/matrix", p); err != nil {
+							t.Errorf("unexpected error from aggregate:\n\t(ERR): %s", err)
+						}
+					})
+					continue
+				}
+				if err := put("1234/matrix", p); err != nil {
+					t.Fatalf("unexpected error from aggregate:\n\t(ERR): %s", err)
+				}
+			}
+			wg.Wait()
+			for _, p := range c.other {
+				if err := put("1234/other", p); err != nil {
+					t.Fatalf("unexpected error from aggregate:\n\t(ERR): %s", err)
+				}
+			}
+
+			var got [][]string
+			for _, m := range f.messages {
+				var texts []string
+				for _, a := range m.Attachments {
+					texts = append(texts, a.Fallback)
+				}
+				got = append(got, texts)
+			}
+
+			want := c.want
+			if c.concurrent {
+				// Concurrent puts are merged in any order.
+				for _, texts := range got {
+					slices.Sort(texts)
+				}
+				want = [][]string{slices.Sorted(slices.Values(c.want[0]))}
+			}
+			if !cmp.Equal(got, want) {
+				t.Fatalf("unexpected messages from aggregate:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, want, cmp.Diff(got, want))
+			}
+			// Later puts edit the message instead of posting their own, so
+			// that the channel is notified once.
+			if c.posts != 0 && f.posts != c.posts {
+				t.Fatalf("unexpected number of posts from aggregate:\n\t(GOT): %d\n\t(WNT): %d", f.posts, c.posts)
+			}
+		})
+	}
+}
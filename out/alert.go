@@ -1,89 +1,100 @@
 package main
 
-import "github.com/arbourd/concourse-slack-alert-resource/concourse"
+import (
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+// builtinAlerts are the built-in alert types by name.
+var builtinAlerts = map[string]Alert{
+	"default": {Type: "default", Message: ""},
+	"success": {Type: "success", Message: "Success"},
+	"failed":  {Type: "failed", Message: "Failed"},
+	"started": {Type: "started", Message: "Started"},
+	"aborted": {Type: "aborted", Message: "Aborted"},
+	"fixed":   {Type: "fixed", Message: "Fixed"},
+	"broke":   {Type: "broke", Message: "Broke"},
+	"errored": {Type: "errored", Message: "Errored"},
+}
 
 // An Alert defines the notification that will be sent to Slack.
 type Alert struct {
 	Type        string
 	Channel     string
+	Channels    []string
 	ChannelFile string
 	Color       string
 	IconURL     string
+	Emoji       string
 	Message     string
 	MessageFile string
+	Mentions    []string
+	Fields      []slack.Field
 	Text        string
 	TextFile    string
+	TextLimit   int
+	TextFormat  string
+	LongText    string
+	StrictFiles bool
 	Disabled    bool
+	Locale      locale
 }
 
-// NewAlert constructs and returns an Alert.
-func NewAlert(input *concourse.OutRequest) Alert {
-	var alert Alert
-	switch input.Params.AlertType {
-	case "success":
-		alert = Alert{
-			Type:    "success",
-			Color:   "#32cd32",
-			IconURL: "https://ci.concourse-ci.org/public/images/favicon-succeeded.png",
-			Message: "Success",
-		}
-	case "failed":
-		alert = Alert{
-			Type:    "failed",
-			Color:   "#d00000",
-			IconURL: "https://ci.concourse-ci.org/public/images/favicon-failed.png",
-			Message: "Failed",
-		}
-	case "started":
-		alert = Alert{
-			Type:    "started",
-			Color:   "#f7cd42",
-			IconURL: "https://ci.concourse-ci.org/public/images/favicon-started.png",
-			Message: "Started",
-		}
-	case "aborted":
-		alert = Alert{
-			Type:    "aborted",
-			Color:   "#8d4b32",
-			IconURL: "https://ci.concourse-ci.org/public/images/favicon-aborted.png",
-			Message: "Aborted",
-		}
-	case "fixed":
-		alert = Alert{
-			Type:    "fixed",
-			Color:   "#32cd32",
-			IconURL: "https://ci.concourse-ci.org/public/images/favicon-succeeded.png",
-			Message: "Fixed",
-		}
-	case "broke":
-		alert = Alert{
-			Type:    "broke",
-			Color:   "#d00000",
-			IconURL: "https://ci.concourse-ci.org/public/images/favicon-failed.png",
-			Message: "Broke",
+// NewAlert constructs and returns an Alert for the build.
+// The alert type is styled by the source's theme. Alert types defined in the
+// source override the built-in alert types, and the first route of the source
+// that matches the alert overrides both. Default messages are translated by
+// the locale.
+func NewAlert(input *concourse.OutRequest, m concourse.BuildMetadata, l locale) Alert {
+	alert, ok := builtinAlerts[input.Params.AlertType]
+	if !ok {
+		alert = builtinAlerts["default"]
+	}
+	alert.Message = l.text(alert.Message)
+	alert.Locale = l
+
+	style := sourceTheme(input.Source.Theme).style(alert.Type)
+	alert.Color = style.Color
+	alert.IconURL = iconURL(input.Source.IconBaseURL, style.Icon)
+	alert.Emoji = style.Emoji
+
+	channel := ""
+	if custom, ok := input.Source.AlertTypes[input.Params.AlertType]; ok {
+		alert.Type = input.Params.AlertType
+		if custom.Color != "" {
+			alert.Color = custom.Color
 		}
-	case "errored":
-		alert = Alert{
-			Type:    "errored",
-			Color:   "#f5a623",
-			IconURL: "https://ci.concourse-ci.org/public/images/favicon-errored.png",
-			Message: "Errored",
+		if custom.IconURL != "" {
+			alert.IconURL = custom.IconURL
 		}
-	default:
-		alert = Alert{
-			Type:    "default",
-			Color:   "#35495c",
-			IconURL: "https://ci.concourse-ci.org/public/images/favicon-pending.png",
-			Message: "",
+		if custom.Message != "" {
+			alert.Message = custom.Message
 		}
+		alert.Mentions = custom.Mentions
+		channel = custom.Channel
+	}
+
+	route, _ := matchRoute(input.Source.Routes, alert.Type, m)
+	if len(route.Mentions) > 0 {
+		alert.Mentions = route.Mentions
 	}
 
 	alert.Disabled = input.Params.Disable
 	if alert.Disabled == false {
 		alert.Disabled = input.Source.Disable
+		if route.Disable != nil {
+			alert.Disabled = *route.Disable
+		}
 	}
 
 	alert.Channel = input.Params.Channel
+	if alert.Channel == "" && len(route.Channels) > 0 {
+		alert.Channel = route.Channels[0]
+		alert.Channels = route.Channels[1:]
+	}
+	if alert.Channel == "" {
+		alert.Channel = channel
+	}
 	if alert.Channel == "" {
 		alert.Channel = input.Source.Channel
 	}
@@ -94,11 +105,19 @@ func NewAlert(input *concourse.OutRequest) Alert {
 	}
 	alert.MessageFile = input.Params.MessageFile
 
+	if len(input.Params.Mentions) > 0 {
+		alert.Mentions = input.Params.Mentions
+	}
+
 	if input.Params.Color != "" {
 		alert.Color = input.Params.Color
 	}
 
 	alert.Text = input.Params.Text
 	alert.TextFile = input.Params.TextFile
+	alert.TextLimit = input.Params.TextLimit
+	alert.TextFormat = input.Params.TextFormat
+	alert.LongText = input.Params.LongText
+	alert.StrictFiles = input.Params.StrictFiles || input.Source.StrictFiles
 	return alert
 }
@@ -9,8 +9,10 @@ import (
 
 func TestNewAlert(t *testing.T) {
 	cases := map[string]struct {
-		input *concourse.OutRequest
-		want  Alert
+		input    *concourse.OutRequest
+		metadata concourse.BuildMetadata
+		locale   locale
+		want     Alert
 	}{
 		// Default and overrides.
 		"default": {
@@ -30,6 +32,111 @@ func TestNewAlert(t *testing.T) {
 			},
 			want: Alert{Type: "default", Channel: "general", Color: "#35495c", IconURL: "https://ci.concourse-ci.org/public/images/favicon-pending.png", Disabled: true},
 		},
+		"strict files": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{StrictFiles: true},
+			},
+			want: Alert{Type: "default", Color: "#35495c", IconURL: "https://ci.concourse-ci.org/public/images/favicon-pending.png", StrictFiles: true},
+		},
+
+		// Routes.
+		"route": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{
+					Channel: "general",
+					Routes: []concourse.Route{
+						{AlertTypes: []string{"success"}, Channels: []string{"ci-noise"}},
+						{AlertTypes: []string{"failed", "broke"}, Pipelines: []string{"prod-*"}, Channels: []string{"oncall", "ci-alerts"}, Mentions: []string{"here"}},
+						{AlertTypes: []string{"failed"}, Channels: []string{"ci-alerts"}},
+					},
+				},
+				Params: concourse.OutParams{AlertType: "failed"},
+			},
+			metadata: concourse.BuildMetadata{TeamName: "main", PipelineName: "prod-deploy", JobName: "deploy"},
+			want:     Alert{Type: "failed", Channel: "oncall", Channels: []string{"ci-alerts"}, Color: "#d00000", IconURL: "https://ci.concourse-ci.org/public/images/favicon-failed.png", Message: "Failed", Mentions: []string{"here"}},
+		},
+		"route overridden by params": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{
+					Routes: []concourse.Route{{Channels: []string{"oncall", "ci-alerts"}, Mentions: []string{"here"}}},
+				},
+				Params: concourse.OutParams{Channel: "custom-channel", Mentions: []string{"U123"}},
+			},
+			want: Alert{Type: "default", Channel: "custom-channel", Color: "#35495c", IconURL: "https://ci.concourse-ci.org/public/images/favicon-pending.png", Mentions: []string{"U123"}},
+		},
+		"route disable": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{
+					Channel: "general",
+					Disable: true,
+					Routes: []concourse.Route{
+						{Jobs: []string{"nightly-*"}, Disable: new(true)},
+						{AlertTypes: []string{"failed"}, Disable: new(false)},
+					},
+				},
+				Params: concourse.OutParams{AlertType: "failed"},
+			},
+			metadata: concourse.BuildMetadata{JobName: "test"},
+			want:     Alert{Type: "failed", Channel: "general", Color: "#d00000", IconURL: "https://ci.concourse-ci.org/public/images/favicon-failed.png", Message: "Failed"},
+		},
+
+		// Custom alert types.
+		"custom alert type": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{
+					Channel: "general",
+					AlertTypes: map[string]concourse.AlertType{
+						"deployed": {Color: "#0000ff", Message: "Deployed", Mentions: []string{"here"}, Channel: "deploys"},
+					},
+				},
+				Params: concourse.OutParams{AlertType: "deployed"},
+			},
+			want: Alert{Type: "deployed", Channel: "deploys", Color: "#0000ff", IconURL: "https://ci.concourse-ci.org/public/images/favicon-pending.png", Message: "Deployed", Mentions: []string{"here"}},
+		},
+		"override built-in alert type": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{
+					AlertTypes: map[string]concourse.AlertType{
+						"failed": {IconURL: "https://example.com/failed.png", Mentions: []string{"U123"}, Channel: "failures"},
+					},
+				},
+				Params: concourse.OutParams{AlertType: "failed", Channel: "custom-channel", Mentions: []string{"U456"}},
+			},
+			want: Alert{Type: "failed", Channel: "custom-channel", Color: "#d00000", IconURL: "https://example.com/failed.png", Message: "Failed", Mentions: []string{"U456"}},
+		},
+
+		// Themes.
+		"icon base url": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{IconBaseURL: "https://static.example.com/icons/"},
+				Params: concourse.OutParams{AlertType: "success"},
+			},
+			want: Alert{Type: "success", Color: "#32cd32", IconURL: "https://static.example.com/icons/favicon-succeeded.png", Message: "Success"},
+		},
+		"colorblind-friendly theme": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{Theme: "colorblind-friendly"},
+				Params: concourse.OutParams{AlertType: "failed"},
+			},
+			want: Alert{Type: "failed", Color: "#d55e00", IconURL: "https://ci.concourse-ci.org/public/images/favicon-failed.png", Message: "Failed"},
+		},
+		"emoji-only theme": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{Theme: "emoji-only"},
+				Params: concourse.OutParams{AlertType: "broke"},
+			},
+			want: Alert{Type: "broke", Color: "#d00000", Emoji: ":boom:", Message: "Broke"},
+		},
+		"theme with custom alert type": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{
+					Theme:      "monochrome",
+					AlertTypes: map[string]concourse.AlertType{"deployed": {Message: "Deployed"}},
+				},
+				Params: concourse.OutParams{AlertType: "deployed"},
+			},
+			want: Alert{Type: "deployed", Color: "#555555", IconURL: "https://ci.concourse-ci.org/public/images/favicon-pending.png", Message: "Deployed"},
+		},
 
 		// Alert types.
 		"success": {
@@ -60,11 +167,21 @@ func TestNewAlert(t *testing.T) {
 			input: &concourse.OutRequest{Params: concourse.OutParams{AlertType: "errored"}},
 			want:  Alert{Type: "errored", Color: "#f5a623", IconURL: "https://ci.concourse-ci.org/public/images/favicon-errored.png", Message: "Errored"},
 		},
+		"localized": {
+			input:  &concourse.OutRequest{Params: concourse.OutParams{AlertType: "failed"}},
+			locale: builtinLocales["de"],
+			want:   Alert{Type: "failed", Color: "#d00000", IconURL: "https://ci.concourse-ci.org/public/images/favicon-failed.png", Message: "Fehlgeschlagen", Locale: builtinLocales["de"]},
+		},
+		"localized with message": {
+			input:  &concourse.OutRequest{Params: concourse.OutParams{AlertType: "failed", Message: "Deploy failed"}},
+			locale: builtinLocales["de"],
+			want:   Alert{Type: "failed", Color: "#d00000", IconURL: "https://ci.concourse-ci.org/public/images/favicon-failed.png", Message: "Deploy failed", Locale: builtinLocales["de"]},
+		},
 	}
 
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
-			got := NewAlert(c.input)
+			got := NewAlert(c.input, c.metadata, c.locale)
 			if !cmp.Equal(got, c.want) {
 				t.Fatalf("unexpected Alert from NewAlert:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
 			}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+const (
+	// defaultMaxAttachments is the number of files uploaded by a put.
+	defaultMaxAttachments = 10
+	// defaultMaxAttachmentSize is the size in bytes of the largest file
+	// uploaded.
+	defaultMaxAttachmentSize = 10 << 20
+)
+
+// attachmentsIn are where attachments can be shared.
+var attachmentsIn = []string{"thread", "channel"}
+
+// readAttachments returns the files of the build directory matching the glob
+// patterns of the attachments param. Files over the limits are skipped,
+// unless strict is set.
+func readAttachments(dir string, params concourse.OutParams, strict bool) ([]slack.File, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	fsys := root.FS()
+
+	names, err := globBuildFiles(fsys, params.Attachments, func(p string, err error) error {
+		return skipAttachment(p, err, strict)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	limit := params.MaxAttachments
+	if limit <= 0 {
+		limit = defaultMaxAttachments
+	}
+	if len(names) > limit {
+		err := fmt.Errorf("%d files match, only the first %d are uploaded", len(names), limit)
+		if err := skipAttachment("attachments", err, strict); err != nil {
+			return nil, err
+		}
+		names = names[:limit]
+	}
+
+	size := params.MaxAttachmentSize
+	if size <= 0 {
+		size = defaultMaxAttachmentSize
+	}
+
+	var files []slack.File
+	for _, name := range names {
+		info, err := fs.Stat(fsys, name)
+		switch {
+		case err != nil:
+		case info.IsDir():
+			continue
+		case info.Size() > size:
+			err = fmt.Errorf("file is larger than %d bytes", size)
+		}
+		if err != nil {
+			if err := skipAttachment(name, err, strict); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			if err := skipAttachment(name, err, strict); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		files = append(files, slack.File{
+			Filename:  path.Base(name),
+			Title:     name,
+			Content:   content,
+			InChannel: params.AttachmentsIn == "channel",
+		})
+	}
+	return files, nil
+}
+
+// skipAttachment returns the error if strict is set, or logs it.
+func skipAttachment(name string, err error, strict bool) error {
+	if err == nil {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf("error reading attachment %s: %w", name, err)
+	}
+	fmt.Fprintf(os.Stderr, "error reading attachment %s: %v\nwill be skipped\n", name, err)
+	return nil
+}
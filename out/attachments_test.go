@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadAttachments(t *testing.T) {
+	cases := map[string]struct {
+		params concourse.OutParams
+		strict bool
+		want   []slack.File
+		err    bool
+	}{
+		"glob": {
+			params: concourse.OutParams{Attachments: []string{"reports/*.html", "screenshots/*.png"}},
+			want: []slack.File{
+				{Filename: "coverage.html", Title: "reports/coverage.html", Content: []byte("<html>")},
+				{Filename: "home.png", Title: "screenshots/home.png", Content: []byte("png")},
+				{Filename: "login.png", Title: "screenshots/login.png", Content: []byte("png")},
+			},
+		},
+		"in channel": {
+			params: concourse.OutParams{Attachments: []string{"reports/coverage.html"}, AttachmentsIn: "channel"},
+			want: []slack.File{
+				{Filename: "coverage.html", Title: "reports/coverage.html", Content: []byte("<html>"), InChannel: true},
+			},
+		},
+		"duplicate matches": {
+			params: concourse.OutParams{Attachments: []string{"reports/*", "reports/coverage.html"}},
+			want: []slack.File{
+				{Filename: "coverage.html", Title: "reports/coverage.html", Content: []byte("<html>")},
+			},
+		},
+		"max attachments": {
+			params: concourse.OutParams{Attachments: []string{"screenshots/*.png"}, MaxAttachments: 1},
+			want: []slack.File{
+				{Filename: "home.png", Title: "screenshots/home.png", Content: []byte("png")},
+			},
+		},
+		"max attachments strict": {
+			params: concourse.OutParams{Attachments: []string{"screenshots/*.png"}, MaxAttachments: 1},
+			strict: true,
+			err:    true,
+		},
+		"max attachment size": {
+			params: concourse.OutParams{Attachments: []string{"reports/*", "screenshots/home.png"}, MaxAttachmentSize: 5},
+			want: []slack.File{
+				{Filename: "home.png", Title: "screenshots/home.png", Content: []byte("png")},
+			},
+		},
+		"no matches": {
+			params: concourse.OutParams{Attachments: []string{"missing/*"}},
+		},
+		"no matches strict": {
+			params: concourse.OutParams{Attachments: []string{"missing/*"}},
+			strict: true,
+			err:    true,
+		},
+		"outside build directory": {
+			params: concourse.OutParams{Attachments: []string{"../secret"}},
+			strict: true,
+			err:    true,
+		},
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "build")
+	files := map[string]string{
+		filepath.Join(dir, "secret"):                    "secret",
+		filepath.Join(path, "reports", "coverage.html"): "<html>",
+		filepath.Join(path, "screenshots", "home.png"):  "png",
+		filepath.Join(path, "screenshots", "login.png"): "png",
+	}
+	for f, contents := range files {
+		if err := os.MkdirAll(filepath.Dir(f), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := readAttachments(path, c.params, c.strict)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from readAttachments:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from readAttachments:\n\t(GOT): nil")
+			} else if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected files from readAttachments:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+// statusSeverity orders build statuses from best to worst. Jobs without a
+// finished build have an empty status.
+var statusSeverity = []string{"", "succeeded", "pending", "started", "aborted", "failed", "errored"}
+
+// statusAlertTypes maps build statuses to the alert type used for their style.
+var statusAlertTypes = map[string]string{
+	"":          "default",
+	"succeeded": "success",
+	"pending":   "started",
+	"started":   "started",
+	"aborted":   "aborted",
+	"failed":    "failed",
+	"errored":   "errored",
+}
+
+// buildDigest returns a message summarizing the latest build status of each
+// of the pipeline's jobs. The message is colored by the worst status.
+func buildDigest(input *concourse.OutRequest, alert Alert, jobs []concourse.Job, m concourse.BuildMetadata) *slack.Message {
+	// Job links keep the instance vars query of the pipeline's URL.
+	pipelineURL, query, _ := strings.Cut(m.PipelineURL, "?")
+	if query != "" {
+		query = "?" + query
+	}
+
+	worst := ""
+	counts := map[string]int{}
+	var lines []string
+	for _, j := range jobs {
+		status := ""
+		if j.FinishedBuild != nil {
+			status = j.FinishedBuild.Status
+		}
+		if slices.Index(statusSeverity, status) > slices.Index(statusSeverity, worst) {
+			worst = status
+		}
+		counts[status]++
+
+		line := fmt.Sprintf("• <%s/jobs/%s%s|%s>: %s", pipelineURL, url.PathEscape(j.Name), query, escape(j.Name), alert.Locale.text(statusLabel(status)))
+		if j.FinishedBuild != nil {
+			line += fmt.Sprintf(" (#%s)", escape(j.FinishedBuild.Name))
+		}
+		if j.Paused {
+			line += " " + alert.Locale.text("(paused)")
+		}
+		lines = append(lines, line)
+	}
+
+	var fields []slack.Field
+	var summary []string
+	for _, status := range slices.Backward(statusSeverity) {
+		if counts[status] == 0 {
+			continue
+		}
+		label := alert.Locale.text(statusLabel(status))
+		fields = append(fields, slack.Field{Title: capitalize(label), Value: fmt.Sprint(counts[status]), Short: true})
+		summary = append(summary, fmt.Sprintf("%d %s", counts[status], label))
+	}
+
+	message := alert.Message
+	if message == "" {
+		message = alert.Locale.text("Summary")
+	}
+
+	style := sourceTheme(input.Source.Theme).style(statusAlertTypes[worst])
+	color := input.Params.Color
+	if color == "" {
+		color = style.Color
+	}
+	author := message
+	if style.Emoji != "" {
+		author = fmt.Sprintf("%s %s", style.Emoji, message)
+	}
+
+	attachment := slack.Attachment{
+		Fallback:   fmt.Sprintf("%s: %s -- %s", message, m.PipelineName, strings.Join(summary, ", ")),
+		AuthorName: author,
+		Color:      color,
+		Footer:     m.PipelineURL,
+		FooterIcon: iconURL(input.Source.IconBaseURL, style.Icon),
+		Fields:     append([]slack.Field{{Title: alert.Locale.text("Pipeline"), Value: escape(m.PipelineName), Short: true}}, fields...),
+		Text:       strings.Join(lines, "\n"),
+	}
+	return &slack.Message{Text: formatMentions(alert.Mentions), Attachments: []slack.Attachment{attachment}, Channel: alert.Channel}
+}
+
+// statusLabel returns a readable label for a build status.
+func statusLabel(status string) string {
+	if status == "" {
+		return "no builds"
+	}
+	return status
+}
+
+// capitalize returns s with its first letter in upper case.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
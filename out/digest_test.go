@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildDigest(t *testing.T) {
+	jobs := []concourse.Job{
+		{Name: "unit", FinishedBuild: &concourse.Build{Name: "12", Status: "succeeded"}},
+		{Name: "integration", FinishedBuild: &concourse.Build{Name: "7", Status: "failed"}},
+		{Name: "deploy", Paused: true},
+	}
+	metadata := concourse.BuildMetadata{
+		Host:         "https://ci.example.com",
+		TeamName:     "main",
+		PipelineName: "demo",
+		URL:          "https://ci.example.com/teams/main/pipelines/demo/jobs/nightly/builds/1",
+		PipelineURL:  "https://ci.example.com/teams/main/pipelines/demo",
+	}
+
+	cases := map[string]struct {
+		input *concourse.OutRequest
+		want  *slack.Message
+	}{
+		"default": {
+			input: &concourse.OutRequest{Params: concourse.OutParams{Digest: true}},
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "Summary: demo -- 1 failed, 1 succeeded, 1 no builds",
+						Color:      "#d00000",
+						AuthorName: "Summary",
+						Fields: []slack.Field{
+							{Title: "Pipeline", Value: "demo", Short: true},
+							{Title: "Failed", Value: "1", Short: true},
+							{Title: "Succeeded", Value: "1", Short: true},
+							{Title: "No builds", Value: "1", Short: true},
+						},
+						Footer:     "https://ci.example.com/teams/main/pipelines/demo",
+						FooterIcon: "https://ci.concourse-ci.org/public/images/favicon-failed.png",
+						Text: "• <https://ci.example.com/teams/main/pipelines/demo/jobs/unit|unit>: succeeded (#12)\n" +
+							"• <https://ci.example.com/teams/main/pipelines/demo/jobs/integration|integration>: failed (#7)\n" +
+							"• <https://ci.example.com/teams/main/pipelines/demo/jobs/deploy|deploy>: no builds (paused)",
+					},
+				},
+			},
+		},
+		"custom message and color": {
+			input: &concourse.OutRequest{
+				Source: concourse.Source{Channel: "nightly"},
+				Params: concourse.OutParams{Digest: true, Message: "Nightly", Color: "#ffffff"},
+			},
+			want: &slack.Message{
+				Channel: "nightly",
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "Nightly: demo -- 1 failed, 1 succeeded, 1 no builds",
+						Color:      "#ffffff",
+						AuthorName: "Nightly",
+						Fields: []slack.Field{
+							{Title: "Pipeline", Value: "demo", Short: true},
+							{Title: "Failed", Value: "1", Short: true},
+							{Title: "Succeeded", Value: "1", Short: true},
+							{Title: "No builds", Value: "1", Short: true},
+						},
+						Footer:     "https://ci.example.com/teams/main/pipelines/demo",
+						FooterIcon: "https://ci.concourse-ci.org/public/images/favicon-failed.png",
+						Text: "• <https://ci.example.com/teams/main/pipelines/demo/jobs/unit|unit>: succeeded (#12)\n" +
+							"• <https://ci.example.com/teams/main/pipelines/demo/jobs/integration|integration>: failed (#7)\n" +
+							"• <https://ci.example.com/teams/main/pipelines/demo/jobs/deploy|deploy>: no builds (paused)",
+					},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := buildDigest(c.input, NewAlert(c.input, metadata, nil), jobs, metadata)
+			if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected slack.Message value from buildDigest:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+
+	// Job links keep the instance vars of the pipeline's URL.
+	vars := metadata
+	vars.PipelineURL = "https://ci.example.com/teams/main/pipelines/demo?vars=%7B%22branch%22%3A%22main%22%7D"
+	input := &concourse.OutRequest{Params: concourse.OutParams{Digest: true}}
+	got := buildDigest(input, NewAlert(input, vars, nil), jobs[:1], vars).Attachments[0]
+	want := "• <https://ci.example.com/teams/main/pipelines/demo/jobs/unit?vars=%7B%22branch%22%3A%22main%22%7D|unit>: succeeded (#12)"
+	if got.Text != want || got.Footer != vars.PipelineURL {
+		t.Fatalf("unexpected links from buildDigest:\n\t(GOT): %#v %#v\n\t(WNT): %#v %#v", got.Text, got.Footer, want, vars.PipelineURL)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+// directRecipients returns the users of dm_users and ephemeral_user, or of
+// their _file params. Users in a file are separated by commas or whitespace.
+func directRecipients(input *concourse.OutRequest, alert Alert, path string) ([]string, string, error) {
+	users, err := readFileParam("dm_users_file", path, input.Params.DMUsersFile, strings.Join(input.Params.DMUsers, "\n"), alert.StrictFiles)
+	if err != nil {
+		return nil, "", err
+	}
+	dmUsers := strings.FieldsFunc(users, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+
+	ephemeralUser, err := readFileParam("ephemeral_user_file", path, input.Params.EphemeralUserFile, input.Params.EphemeralUser, alert.StrictFiles)
+	if err != nil {
+		return nil, "", err
+	}
+	return dmUsers, ephemeralUser, nil
+}
+
+// sendDirect sends the message to each of the users in a direct message, and
+// posts it in its channel visible only to the ephemeral user. Users are Slack
+// user IDs or email addresses.
+func sendDirect(input *concourse.OutRequest, atype string, message *slack.Message, dmUsers []string, ephemeralUser string) (*concourse.OutResponse, error) {
+	m := *message
+	m.Metadata = nil
+	if err := prepare(input, &m); err != nil {
+		return nil, err
+	}
+
+	dryRun := input.Params.DryRun || input.Source.DryRun
+	o := buildOut(atype, m.Channel, !dryRun)
+	if len(dmUsers) > 0 {
+		o.Metadata = append(o.Metadata, concourse.Metadata{Name: "dm_users", Value: strings.Join(dmUsers, ",")})
+	}
+	if ephemeralUser != "" {
+		o.Metadata = append(o.Metadata, concourse.Metadata{Name: "ephemeral_user", Value: ephemeralUser})
+	}
+
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "dry run, not sending slack message to users: %s\n", strings.TrimSpace(strings.Join(dmUsers, " ")+" "+ephemeralUser))
+		return o, nil
+	}
+
+	c := slack.NewClient(input.Source.Token, retryPolicy(input.Source.Retry))
+	if err := deliverDirect(c, &m, dmUsers, ephemeralUser); err != nil {
+		return failed(input, atype, m.Channel, fmt.Errorf("error sending slack message: %w", err))
+	}
+	return o, nil
+}
+
+// deliverDirect opens a direct message with each of the users to send the
+// message, then posts the message to the ephemeral user.
+func deliverDirect(c *slack.Client, m *slack.Message, dmUsers []string, ephemeralUser string) error {
+	for _, u := range dmUsers {
+		id, err := lookupUser(c, u)
+		if err != nil {
+			return err
+		}
+		channel, err := c.OpenConversation(id)
+		if err != nil {
+			return fmt.Errorf("error opening direct message with %s: %w", u, err)
+		}
+
+		dm := *m
+		dm.Channel = channel
+		if err := c.Send(&dm); err != nil {
+			return fmt.Errorf("error sending direct message to %s: %w", u, err)
+		}
+	}
+
+	if ephemeralUser != "" {
+		id, err := lookupUser(c, ephemeralUser)
+		if err != nil {
+			return err
+		}
+		if err := c.PostEphemeral(id, m); err != nil {
+			return fmt.Errorf("error posting ephemeral message to %s: %w", ephemeralUser, err)
+		}
+	}
+	return nil
+}
+
+// lookupUser returns the ID of the user, looking up email addresses.
+func lookupUser(c *slack.Client, user string) (string, error) {
+	if !strings.Contains(user, "@") {
+		return user, nil
+	}
+	id, err := c.LookupUserByEmail(user)
+	if err != nil {
+		return "", fmt.Errorf("error looking up user %s: %w", user, err)
+	}
+	return id, nil
+}
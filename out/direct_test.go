@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSendDirect(t *testing.T) {
+	var requests []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users.lookupByEmail" {
+			r.ParseForm()
+			requests = append(requests, "lookup "+r.Form.Get("email"))
+			if r.Form.Get("email") != "dev@example.com" {
+				w.Write([]byte(`{"ok":false,"error":"users_not_found"}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true,"user":{"id":"U123"}}`))
+			return
+		}
+
+		var body struct {
+			slack.Message
+			User  string `json:"user"`
+			Users string `json:"users"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/chat.postMessage":
+			requests = append(requests, "post "+body.Channel)
+			w.Write([]byte(`{"ok":true,"channel":"C123","ts":"1.000000"}`))
+		case "/conversations.open":
+			requests = append(requests, "open "+body.Users)
+			w.Write([]byte(`{"ok":true,"channel":{"id":"D` + body.Users[1:] + `"}}`))
+		case "/chat.postEphemeral":
+			requests = append(requests, "ephemeral "+body.Channel+" "+body.User)
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	url := slack.APIURL
+	slack.APIURL = s.URL
+	defer func() { slack.APIURL = url }()
+
+	for k, v := range map[string]string{
+		"ATC_EXTERNAL_URL":    "https://ci.example.com",
+		"BUILD_TEAM_NAME":     "main",
+		"BUILD_PIPELINE_NAME": "demo",
+		"BUILD_JOB_NAME":      "test",
+		"BUILD_NAME":          "2",
+	} {
+		t.Setenv(k, v)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "committer"), []byte("dev@example.com\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	source := concourse.Source{Token: "xoxb-token", Channel: "concourse"}
+	cases := map[string]struct {
+		source   concourse.Source
+		params   concourse.OutParams
+		want     *concourse.OutResponse
+		requests []string
+		payload  bool
+		err      bool
+	}{
+		"dm users and channel": {
+			source: source,
+			params: concourse.OutParams{AlertType: "failed", DMUsers: []string{"U456", "dev@example.com"}},
+			want: &concourse.OutResponse{
+				Version: concourse.Version{"ver": "static"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "failed"},
+					{Name: "channel", Value: "concourse"},
+					{Name: "alerted", Value: "true"},
+					{Name: "dm_users", Value: "U456,dev@example.com"},
+				},
+			},
+			requests: []string{"post concourse", "open U456", "post D456", "lookup dev@example.com", "open U123", "post D123"},
+		},
+		"ephemeral user file without channel": {
+			source: source,
+			params: concourse.OutParams{AlertType: "failed", EphemeralUserFile: "committer", SkipChannel: true},
+			want: &concourse.OutResponse{
+				Version: concourse.Version{"ver": "static"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "failed"},
+					{Name: "channel", Value: "concourse"},
+					{Name: "alerted", Value: "true"},
+					{Name: "ephemeral_user", Value: "dev@example.com"},
+				},
+			},
+			requests: []string{"lookup dev@example.com", "ephemeral concourse U123"},
+		},
+		"unknown email": {
+			source: concourse.Source{Token: "xoxb-token", Channel: "concourse", FailOnError: new(false)},
+			params: concourse.OutParams{AlertType: "failed", DMUsers: []string{"other@example.com"}},
+			want: &concourse.OutResponse{
+				Version: concourse.Version{"ver": "static"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "failed"},
+					{Name: "channel", Value: "concourse"},
+					{Name: "alerted", Value: "true"},
+					{Name: "error", Value: "error sending slack message: error looking up user other@example.com: users_not_found"},
+				},
+			},
+			requests: []string{"post concourse", "lookup other@example.com"},
+		},
+		"dry run with dm users": {
+			source: concourse.Source{Token: "xoxb-token", Channel: "concourse", DryRun: true},
+			params: concourse.OutParams{AlertType: "failed", DMUsers: []string{"U456"}},
+			want: &concourse.OutResponse{
+				Version: concourse.Version{"ver": "static"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "failed"},
+					{Name: "channel", Value: "concourse"},
+					{Name: "alerted", Value: "false"},
+					{Name: "dm_users", Value: "U456"},
+				},
+			},
+			payload: true,
+		},
+		"skip channel without recipients": {
+			source: source,
+			params: concourse.OutParams{AlertType: "failed", DMUsersFile: "missing", SkipChannel: true},
+			err:    true,
+		},
+		"unknown email failing": {
+			source:   source,
+			params:   concourse.OutParams{AlertType: "failed", DMUsers: []string{"other@example.com"}, SkipChannel: true},
+			requests: []string{"lookup other@example.com"},
+			err:      true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			requests = nil
+			got, err := out(&concourse.OutRequest{Source: c.source, Params: c.params}, dir)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from out:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from out:\n\t(GOT): nil")
+			}
+			if c.payload {
+				// The payload of the channel message is reported by a dry run.
+				i := slices.IndexFunc(got.Metadata, func(m concourse.Metadata) bool { return m.Name == "payload" })
+				if i < 0 {
+					t.Fatalf("expected payload metadata from out:\n\t(GOT): %#v", got.Metadata)
+				}
+				got.Metadata = slices.Delete(got.Metadata, i, i+1)
+			}
+			if !c.err && !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected concourse.OutResponse value from out:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+			if !cmp.Equal(requests, c.requests) {
+				t.Fatalf("unexpected requests to Slack:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", requests, c.requests, cmp.Diff(requests, c.requests))
+			}
+		})
+	}
+}
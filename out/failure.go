@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+// eventsWait is how long the events of the running build are read for.
+var eventsWait = 5 * time.Second
+
+const (
+	defaultLogLines = 10
+	maxLogLength    = 1500
+)
+
+// ansiRegexp matches ANSI escape codes, like colors, in logs.
+var ansiRegexp = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// failedStepFields returns fields with the name of the build's failed step
+// and the last lines of its logs. No fields are returned if no step failed.
+// Secrets are redacted from the whole log before it is cut to the excerpt,
+// so that secrets spanning lines are still matched.
+func failedStepFields(c *concourse.Client, id string, lines int, l locale, r *redactor) ([]slack.Field, error) {
+	plan, err := c.BuildPlan(id)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting build plan: %w", err)
+	}
+
+	events, err := c.BuildEvents(id, eventsWait)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting build events: %w", err)
+	}
+
+	// The last failure is reported, as earlier failures may have been
+	// handled by the build (e.g. with `try` or `attempts`).
+	var failed *concourse.Event
+	logs := map[string]*strings.Builder{}
+	for _, e := range events {
+		if e.Event == "log" {
+			if logs[e.Data.Origin.ID] == nil {
+				logs[e.Data.Origin.ID] = &strings.Builder{}
+			}
+			logs[e.Data.Origin.ID].WriteString(e.Data.Payload)
+		}
+		if e.Failed() {
+			failed = &e
+		}
+	}
+	if failed == nil {
+		return nil, nil
+	}
+
+	name, ok := plan.StepNames()[failed.Data.Origin.ID]
+	if !ok {
+		name = failed.Data.Origin.ID
+	}
+	fields := []slack.Field{{Title: l.text("Failed step"), Value: name, Short: true}}
+
+	log := ""
+	if b := logs[failed.Data.Origin.ID]; b != nil {
+		log = b.String()
+	}
+	if failed.Event == "error" {
+		log += "\n" + failed.Data.Message
+	}
+
+	if r != nil {
+		log = r.redact(log)
+	}
+	if excerpt := logExcerpt(log, lines); excerpt != "" {
+		fields = append(fields, slack.Field{Title: l.text("Log"), Value: fmt.Sprintf("```\n%s\n```", excerpt)})
+	}
+	return fields, nil
+}
+
+// logExcerpt returns the last lines of the log without escape codes,
+// truncated to fit in a message.
+func logExcerpt(log string, lines int) string {
+	log = ansiRegexp.ReplaceAllString(log, "")
+	log = strings.ReplaceAll(log, "```", "'''")
+
+	var kept []string
+	for _, line := range strings.Split(log, "\n") {
+		// Only keep the final state of lines rewritten with carriage returns,
+		// like progress bars.
+		if i := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); i > -1 {
+			line = line[i+1:]
+		}
+		kept = append(kept, strings.TrimRight(line, "\r "))
+	}
+
+	excerpt := strings.Trim(strings.Join(kept, "\n"), "\n")
+	kept = strings.Split(excerpt, "\n")
+	if len(kept) > lines {
+		kept = kept[len(kept)-lines:]
+	}
+
+	excerpt = strings.Join(kept, "\n")
+	if len(excerpt) > maxLogLength {
+		i := len(excerpt) - maxLogLength
+		for !utf8.RuneStart(excerpt[i]) {
+			i++
+		}
+		excerpt = "…" + excerpt[i:]
+	}
+	return excerpt
+}
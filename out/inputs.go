@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+const maxCommitMessageLength = 80
+
+// An inputFormatter formats the version of an input for an alert. ok is false
+// if the formatter does not apply to the version.
+type inputFormatter func(in input) (value string, ok bool)
+
+// An input is a build's input and the metadata of its version.
+type input struct {
+	concourse.BuildInput
+	Metadata map[string]string
+	URL      string
+}
+
+// inputFormatters are tried in order until one applies to the version.
+var inputFormatters = []inputFormatter{formatGitInput, formatSemverInput, formatImageInput}
+
+// inputFields returns a field for each of the build's inputs describing their
+// version. The URL templates by resource name are used to link to versions.
+func inputFields(c *concourse.Client, m concourse.BuildMetadata, urls map[string]string) ([]slack.Field, error) {
+	resources, err := c.BuildResources(m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting build resources: %w", err)
+	}
+
+	var fields []slack.Field
+	for _, bi := range resources.Inputs {
+		in := input{BuildInput: bi}
+		resource := bi.Resource
+		if resource == "" {
+			resource = bi.Name
+		}
+
+		// Only git versions have metadata worth requesting.
+		if bi.Version["ref"] != "" {
+			rv, err := c.ResourceVersion(m.PipelineName, resource, bi.Version, m.InstanceVars)
+			if err == nil {
+				in.Metadata = map[string]string{}
+				for _, md := range rv.Metadata {
+					in.Metadata[md.Name] = md.Value
+				}
+			}
+		}
+
+		if tmpl, ok := urls[resource]; ok {
+			in.URL = expandURL(tmpl, bi.Version)
+		}
+
+		fields = append(fields, slack.Field{Title: bi.Name, Value: formatInput(in), Short: true})
+	}
+	return fields, nil
+}
+
+// formatInput formats the input with the first formatter that applies.
+func formatInput(in input) string {
+	for _, f := range inputFormatters {
+		if v, ok := f(in); ok {
+			return v
+		}
+	}
+
+	var pairs []string
+	for _, k := range slices.Sorted(maps.Keys(in.Version)) {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", k, in.Version[k]))
+	}
+	return link(in.URL, fmt.Sprintf("`%s`", escape(strings.Join(pairs, ", "))))
+}
+
+// formatGitInput formats a git commit with its message and author.
+func formatGitInput(in input) (string, bool) {
+	ref, ok := in.Version["ref"]
+	if !ok {
+		return "", false
+	}
+
+	short := ref
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	value := link(in.URL, fmt.Sprintf("`%s`", escape(short)))
+
+	if message, _, _ := strings.Cut(in.Metadata["message"], "\n"); message != "" {
+		if r := []rune(message); len(r) > maxCommitMessageLength {
+			message = string(r[:maxCommitMessageLength]) + "…"
+		}
+		value = fmt.Sprintf("%s %s", value, escape(message))
+	}
+	if author := in.Metadata["author"]; author != "" {
+		value = fmt.Sprintf("%s (%s)", value, escape(author))
+	}
+	return value, true
+}
+
+// formatSemverInput formats a semver version number.
+func formatSemverInput(in input) (string, bool) {
+	number, ok := in.Version["number"]
+	if !ok {
+		return "", false
+	}
+	return link(in.URL, escape(number)), true
+}
+
+// formatImageInput formats an image digest.
+func formatImageInput(in input) (string, bool) {
+	digest, ok := in.Version["digest"]
+	if !ok {
+		return "", false
+	}
+
+	algorithm, hash, found := strings.Cut(digest, ":")
+	if found && len(hash) > 12 {
+		digest = fmt.Sprintf("%s:%s", algorithm, hash[:12])
+	}
+	if tag := in.Version["tag"]; tag != "" {
+		digest = fmt.Sprintf("%s@%s", tag, digest)
+	}
+	return link(in.URL, fmt.Sprintf("`%s`", escape(digest))), true
+}
+
+// expandURL replaces the {key} placeholders in the URL template with the
+// values of the version.
+func expandURL(tmpl string, v concourse.Version) string {
+	var pairs []string
+	for k, value := range v {
+		pairs = append(pairs, fmt.Sprintf("{%s}", k), value)
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+// link returns the text as a Slack link to the URL. The text is returned
+// unchanged without a URL.
+func link(url, text string) string {
+	if url == "" {
+		return text
+	}
+	return fmt.Sprintf("<%s|%s>", url, text)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInputFields(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/builds/42/resources":
+			fmt.Fprint(w, `{"inputs":[{"name":"repo","version":{"ref":"0123456789abcdef"}},{"name":"version","version":{"number":"1.2.3"}}]}`)
+		case "/api/v1/teams/main/pipelines/demo/resources/repo/versions":
+			fmt.Fprint(w, `[{"id":1,"version":{"ref":"0123456789abcdef"},"metadata":[{"name":"message","value":"Fix <thing>\n\nDetails"},{"name":"author","value":"Jane Doe"}]}]`)
+		default:
+			http.Error(w, "", http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	client, err := concourse.NewClient(s.URL, "main", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := concourse.BuildMetadata{ID: "42", TeamName: "main", PipelineName: "demo"}
+	urls := map[string]string{"repo": "https://github.com/example/repo/commit/{ref}"}
+
+	want := []slack.Field{
+		{Title: "repo", Value: "<https://github.com/example/repo/commit/0123456789abcdef|`0123456`> Fix &lt;thing&gt; (Jane Doe)", Short: true},
+		{Title: "version", Value: "1.2.3", Short: true},
+	}
+
+	got, err := inputFields(client, m, urls)
+	if err != nil {
+		t.Fatalf("unexpected error from inputFields:\n\t(ERR): %s", err)
+	} else if !cmp.Equal(got, want) {
+		t.Fatalf("unexpected fields from inputFields:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, want, cmp.Diff(got, want))
+	}
+}
+
+func TestFormatInput(t *testing.T) {
+	cases := map[string]struct {
+		input input
+		want  string
+	}{
+		"git without metadata": {
+			input: input{BuildInput: concourse.BuildInput{Version: concourse.Version{"ref": "0123456789abcdef"}}},
+			want:  "`0123456`",
+		},
+		"semver with url": {
+			input: input{BuildInput: concourse.BuildInput{Version: concourse.Version{"number": "1.2.3"}}, URL: "https://example.com/1.2.3"},
+			want:  "<https://example.com/1.2.3|1.2.3>",
+		},
+		"image": {
+			input: input{BuildInput: concourse.BuildInput{Version: concourse.Version{"digest": "sha256:0123456789abcdef0123", "tag": "latest"}}},
+			want:  "`latest@sha256:0123456789ab`",
+		},
+		"other": {
+			input: input{BuildInput: concourse.BuildInput{Version: concourse.Version{"path": "a.tgz", "id": "7"}}},
+			want:  "`id: 7, path: a.tgz`",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := formatInput(c.input)
+			if got != c.want {
+				t.Fatalf("unexpected value from formatInput:\n\t(GOT): %#v\n\t(WNT): %#v", got, c.want)
+			}
+		})
+	}
+}
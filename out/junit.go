@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+const (
+	// defaultMaxFailedTests is the number of failed tests listed.
+	defaultMaxFailedTests = 10
+	// maxTestNameLength is the number of characters of a test name shown.
+	maxTestNameLength = 100
+)
+
+// A testReport summarizes the results of test reports.
+type testReport struct {
+	Total   int
+	Failed  int
+	Skipped int
+	// Failures are the names of failed tests.
+	Failures []string
+}
+
+// junitSuite is a JUnit XML <testsuites> or <testsuite> element.
+type junitSuite struct {
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+// junitCase is a JUnit XML <testcase> element.
+type junitCase struct {
+	Name      string    `xml:"name,attr"`
+	Classname string    `xml:"classname,attr"`
+	Failure   *struct{} `xml:"failure"`
+	Error     *struct{} `xml:"error"`
+	Skipped   *struct{} `xml:"skipped"`
+}
+
+// add adds the test cases of the suite and its nested suites to the report.
+func (s junitSuite) add(r *testReport) {
+	for _, c := range s.Cases {
+		r.Total++
+		switch {
+		case c.Failure != nil || c.Error != nil:
+			r.Failed++
+			name := c.Name
+			if c.Classname != "" {
+				name = fmt.Sprintf("%s.%s", c.Classname, c.Name)
+			}
+			r.Failures = append(r.Failures, name)
+		case c.Skipped != nil:
+			r.Skipped++
+		}
+	}
+	for _, n := range s.Suites {
+		n.add(r)
+	}
+}
+
+// testEvent is an event of Go's test2json output.
+// https://pkg.go.dev/cmd/test2json
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+}
+
+// parseTestReport adds the results of the JUnit XML or test2json report to
+// the report.
+func parseTestReport(b []byte, r *testReport) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '<' {
+		var s junitSuite
+		if err := xml.Unmarshal(b, &s); err != nil {
+			return fmt.Errorf("error parsing JUnit XML: %w", err)
+		}
+		s.add(r)
+		return nil
+	}
+
+	// Only leaf tests are counted, as a test with subtests also reports a
+	// result once its subtests have.
+	parents := map[string]bool{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(nil, 1<<20)
+	for n := 1; s.Scan(); n++ {
+		var e testEvent
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return fmt.Errorf("error parsing test2json line %d: %w", n, err)
+		}
+		if e.Test == "" {
+			continue
+		}
+		for i, c := range e.Test {
+			if c == '/' {
+				parents[e.Package+" "+e.Test[:i]] = true
+			}
+		}
+		if parents[e.Package+" "+e.Test] {
+			continue
+		}
+
+		switch e.Action {
+		case "pass":
+			r.Total++
+		case "fail":
+			r.Total++
+			r.Failed++
+			r.Failures = append(r.Failures, fmt.Sprintf("%s.%s", path.Base(e.Package), e.Test))
+		case "skip":
+			r.Total++
+			r.Skipped++
+		}
+	}
+	return s.Err()
+}
+
+// readTestReports returns the report of the test reports of the build
+// directory matching the glob patterns. Reports that cannot be read are
+// skipped, unless strict is set.
+func readTestReports(dir string, patterns []string, strict bool) (testReport, error) {
+	var r testReport
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return r, err
+	}
+	defer root.Close()
+	fsys := root.FS()
+
+	names, err := globBuildFiles(fsys, patterns, func(p string, err error) error {
+		return skipTestReport(p, err, strict)
+	})
+	if err != nil {
+		return r, err
+	}
+
+	for _, name := range names {
+		b, err := fs.ReadFile(fsys, name)
+		if err == nil {
+			err = parseTestReport(b, &r)
+		}
+		if err := skipTestReport(name, err, strict); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// skipTestReport returns the error if strict is set, or logs it.
+func skipTestReport(name string, err error, strict bool) error {
+	if err == nil {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf("error reading test report %s: %w", name, err)
+	}
+	fmt.Fprintf(os.Stderr, "error reading test report %s: %v\nwill be skipped\n", name, err)
+	return nil
+}
+
+// testReportFields returns fields summarizing the test reports of the build,
+// and listing up to limit of the failed tests.
+func testReportFields(r testReport, limit int, m concourse.BuildMetadata, l locale) []slack.Field {
+	if r.Total == 0 {
+		return nil
+	}
+	if limit <= 0 {
+		limit = defaultMaxFailedTests
+	}
+
+	summary := l.format("All %d tests passed", r.Total-r.Skipped)
+	if r.Failed > 0 {
+		summary = l.format("%d of %d tests failed", r.Failed, r.Total)
+	}
+	if r.Skipped > 0 {
+		summary += " " + l.format("(%d skipped)", r.Skipped)
+	}
+	fields := []slack.Field{{Title: l.text("Tests"), Value: summary, Short: true}}
+	if r.Failed == 0 {
+		return fields
+	}
+
+	var lines []string
+	for _, name := range r.Failures[:min(limit, len(r.Failures))] {
+		if n := []rune(name); len(n) > maxTestNameLength {
+			name = string(n[:maxTestNameLength-1]) + "…"
+		}
+		lines = append(lines, fmt.Sprintf("• `%s`", escape(name)))
+	}
+	if more := len(r.Failures) - limit; more > 0 {
+		lines = append(lines, l.format("…and %s", link(m.URL, l.format("%d more", more))))
+	}
+	fields = append(fields, slack.Field{Title: l.text("Failed tests"), Value: strings.Join(lines, "\n")})
+	return fields
+}
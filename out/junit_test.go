@@ -0,0 +1,165 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+	"github.com/google/go-cmp/cmp"
+)
+
+const junitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="api" tests="3">
+    <testcase classname="api.UsersTest" name="testCreate"/>
+    <testcase classname="api.UsersTest" name="testDelete">
+      <failure message="expected 204">stack trace</failure>
+    </testcase>
+    <testcase classname="api.UsersTest" name="testList"><skipped/></testcase>
+  </testsuite>
+  <testsuite name="web">
+    <testsuite name="nested">
+      <testcase name="renders"><error message="timeout"/></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+const test2jsonReport = `{"Action":"run","Package":"example.com/app/store","Test":"TestGet"}
+{"Action":"output","Package":"example.com/app/store","Test":"TestGet","Output":"--- PASS: TestGet\n"}
+{"Action":"pass","Package":"example.com/app/store","Test":"TestGet"}
+{"Action":"fail","Package":"example.com/app/store","Test":"TestPut/overwrite"}
+{"Action":"fail","Package":"example.com/app/store","Test":"TestPut"}
+{"Action":"skip","Package":"example.com/app/store","Test":"TestSlow"}
+{"Action":"fail","Package":"example.com/app/store"}
+`
+
+func TestParseTestReport(t *testing.T) {
+	cases := map[string]struct {
+		report string
+		want   testReport
+		err    bool
+	}{
+		"junit": {
+			report: junitReport,
+			want:   testReport{Total: 4, Failed: 2, Skipped: 1, Failures: []string{"api.UsersTest.testDelete", "renders"}},
+		},
+		"junit testsuite": {
+			report: `<testsuite><testcase name="a"/><testcase name="b"><failure/></testcase></testsuite>`,
+			want:   testReport{Total: 2, Failed: 1, Failures: []string{"b"}},
+		},
+		"test2json": {
+			report: test2jsonReport,
+			want:   testReport{Total: 3, Failed: 1, Skipped: 1, Failures: []string{"store.TestPut/overwrite"}},
+		},
+		"test2json subtests": {
+			report: `{"Action":"run","Package":"example.com/app/p","Test":"TestA"}
+{"Action":"run","Package":"example.com/app/p","Test":"TestA/sub"}
+{"Action":"fail","Package":"example.com/app/p","Test":"TestA/sub"}
+{"Action":"pass","Package":"example.com/app/p","Test":"TestA/other"}
+{"Action":"fail","Package":"example.com/app/p","Test":"TestA"}
+{"Action":"pass","Package":"example.com/app/p","Test":"TestB"}
+{"Action":"fail","Package":"example.com/app/p"}`,
+			want: testReport{Total: 3, Failed: 1, Failures: []string{"p.TestA/sub"}},
+		},
+		"invalid junit": {
+			report: `<testsuite><testcase>`,
+			err:    true,
+		},
+		"invalid test2json": {
+			report: `--- FAIL: TestGet`,
+			err:    true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got testReport
+			err := parseTestReport([]byte(c.report), &got)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from parseTestReport:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from parseTestReport:\n\t(GOT): nil")
+			} else if !c.err && !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected testReport value from parseTestReport:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
+
+func TestReadTestReports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		filepath.Join(dir, "reports", "junit.xml"): junitReport,
+		filepath.Join(dir, "reports", "go.json"):   test2jsonReport,
+		filepath.Join(dir, "reports", "bad.xml"):   "<testsuite>",
+	}
+	for f, contents := range files {
+		if err := os.MkdirAll(filepath.Dir(f), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := readTestReports(dir, []string{"reports/junit.xml", "reports/*.json", "reports/*", "missing/*.xml"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error from readTestReports:\n\t(ERR): %s", err)
+	}
+	want := testReport{
+		Total:    7,
+		Failed:   3,
+		Skipped:  2,
+		Failures: []string{"api.UsersTest.testDelete", "renders", "store.TestPut/overwrite"},
+	}
+	if !cmp.Equal(got, want) {
+		t.Fatalf("unexpected testReport value from readTestReports:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, want, cmp.Diff(got, want))
+	}
+
+	if _, err := readTestReports(dir, []string{"reports/*.xml"}, true); err == nil {
+		t.Fatalf("expected an error from readTestReports:\n\t(GOT): nil")
+	}
+}
+
+func TestTestReportFields(t *testing.T) {
+	m := concourse.BuildMetadata{URL: "https://ci.example.com/builds/1"}
+
+	cases := map[string]struct {
+		report testReport
+		limit  int
+		want   []slack.Field
+	}{
+		"no tests": {},
+		"passed": {
+			report: testReport{Total: 840, Skipped: 3},
+			want:   []slack.Field{{Title: "Tests", Value: "All 837 tests passed (3 skipped)", Short: true}},
+		},
+		"failed": {
+			report: testReport{Total: 840, Failed: 3, Failures: []string{"a", "<b>", strings.Repeat("c", 120)}},
+			limit:  2,
+			want: []slack.Field{
+				{Title: "Tests", Value: "3 of 840 tests failed", Short: true},
+				{Title: "Failed tests", Value: "• `a`\n• `&lt;b&gt;`\n…and <https://ci.example.com/builds/1|1 more>"},
+			},
+		},
+		"long name": {
+			report: testReport{Total: 1, Failed: 1, Failures: []string{strings.Repeat("c", 120)}},
+			want: []slack.Field{
+				{Title: "Tests", Value: "1 of 1 tests failed", Short: true},
+				{Title: "Failed tests", Value: "• `" + strings.Repeat("c", 99) + "…`"},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := testReportFields(c.report, c.limit, m, nil)
+			if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected fields from testReportFields:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// local runs the out operation as a dry run outside of a build, and writes
+// the payload of the message to w. The request and the build metadata env
+// vars are read from files.
+func local(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("out", flag.ContinueOnError)
+	request := fs.String("request", "", "`file` containing the JSON request of the out operation")
+	env := fs.String("env", "", "`file` containing the build metadata env vars as KEY=VALUE lines")
+	dir := fs.String("dir", ".", "the build `directory` that _file params are read from")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *request == "" {
+		return errors.New("-request cannot be blank")
+	}
+
+	// The env is read first, as the routes of the request are validated
+	// against the build metadata.
+	if *env != "" {
+		if err := loadEnv(*env); err != nil {
+			return fmt.Errorf("error reading env: %w", err)
+		}
+	}
+
+	f, err := os.Open(*request)
+	if err != nil {
+		return fmt.Errorf("error reading request: %w", err)
+	}
+	defer f.Close()
+
+	input, err := decodeInput(f)
+	if err != nil {
+		return fmt.Errorf("error reading request: %w", err)
+	}
+	input.Params.DryRun = true
+
+	o, err := out(input, *dir)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range o.Metadata {
+		if m.Name != "payload" {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(m.Value), "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := buf.WriteTo(w)
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "no message would be sent")
+	return nil
+}
+
+// loadEnv sets the env vars of the file. Lines are KEY=VALUE pairs, and
+// blank lines and lines starting with # are ignored.
+func loadEnv(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		k, v, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		v = strings.TrimSpace(v)
+		if len(v) > 1 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		if err := os.Setenv(strings.TrimSpace(k), v); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return s.Err()
+}
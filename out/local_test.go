@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocal(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to Slack during dry run: %s", r.URL)
+	}))
+	defer s.Close()
+
+	cases := map[string]struct {
+		request string
+		env     string
+		want    *slack.Message
+		err     bool
+	}{
+		"job build": {
+			request: `{"source":{"url":"` + s.URL + `","channel":"concourse"},"params":{"alert_type":"success","text_file":"notes"}}`,
+			env: `# build metadata
+ATC_EXTERNAL_URL=https://ci.example.com
+export BUILD_TEAM_NAME=main
+BUILD_PIPELINE_NAME="demo"
+BUILD_JOB_NAME='test'
+BUILD_NAME=2
+`,
+			want: &slack.Message{
+				Channel: "concourse",
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "Success: demo/test/2 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/2",
+						Color:      "#32cd32",
+						AuthorName: "Success",
+						Title:      "demo/test #2",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/2",
+						Fields: []slack.Field{
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/2|2>", Short: true},
+						},
+						Footer:     "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/2",
+						FooterIcon: "https://ci.concourse-ci.org/public/images/favicon-succeeded.png",
+						Text:       "release notes",
+						MrkdwnIn:   []string{"text"},
+					},
+				},
+			},
+		},
+		"invalid env": {
+			request: `{"source":{"url":"` + s.URL + `"}}`,
+			env:     "BUILD_NAME",
+			err:     true,
+		},
+		"invalid request": {
+			request: `{"source":{}}`,
+			err:     true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			for _, k := range []string{"ATC_EXTERNAL_URL", "BUILD_ID", "BUILD_TEAM_NAME", "BUILD_PIPELINE_NAME", "BUILD_PIPELINE_INSTANCE_VARS", "BUILD_JOB_NAME", "BUILD_NAME"} {
+				t.Setenv(k, "")
+			}
+
+			dir := t.TempDir()
+			request := filepath.Join(dir, "request.json")
+			env := filepath.Join(dir, "build.env")
+			files := map[string]string{request: c.request, env: c.env, filepath.Join(dir, "notes"): "release notes"}
+			for f, contents := range files {
+				if err := os.WriteFile(f, []byte(contents), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var stdout bytes.Buffer
+			err := local([]string{"-request", request, "-env", env, "-dir", dir}, &stdout)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from local:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from local:\n\t(GOT): nil")
+			} else if c.err {
+				return
+			}
+
+			var got *slack.Message
+			if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+				t.Fatalf("unexpected payload from local:\n\t(ERR): %s\n\t(GOT): %s", err, stdout.String())
+			}
+			if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected slack.Message value from local:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+)
+
+// A locale translates the default strings of messages. Strings are keyed by
+// their English text, which is used when a string has no translation.
+type locale map[string]string
+
+// text returns the translation of s.
+func (l locale) text(s string) string {
+	if t, ok := l[s]; ok && t != "" {
+		return t
+	}
+	return s
+}
+
+// format formats the translation of the format string.
+func (l locale) format(format string, a ...any) string {
+	return fmt.Sprintf(l.text(format), a...)
+}
+
+// duration formats d in hours and minutes, like "2h 5m".
+func (l locale) duration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+
+	var parts []string
+	if h > 0 {
+		parts = append(parts, l.format("%dh", h))
+	}
+	if m > 0 || h == 0 {
+		parts = append(parts, l.format("%dm", m))
+	}
+	return strings.Join(parts, " ")
+}
+
+// loadLocale returns the built-in locale of the source, with the strings of
+// its translations file added. If the file cannot be read, the built-in
+// locale is used unless strict_files is set.
+func loadLocale(source concourse.Source, path string) (locale, error) {
+	l := maps.Clone(builtinLocales[source.Locale])
+	if l == nil {
+		l = locale{}
+	}
+	if source.TranslationsFile == "" {
+		return l, nil
+	}
+
+	contents, err := readBuildFile(path, source.TranslationsFile)
+	if err == nil {
+		var custom map[string]string
+		if err = json.Unmarshal([]byte(contents), &custom); err == nil {
+			maps.Copy(l, custom)
+			return l, nil
+		}
+	}
+
+	if source.StrictFiles {
+		return nil, fmt.Errorf("error reading translations_file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "error reading translations_file: %v\nwill default to locale instead\n", err)
+	return l, nil
+}
+
+// builtinLocales are the built-in translations by locale.
+var builtinLocales = map[string]locale{
+	"en": {},
+	"de": {
+		"Success":                           "Erfolgreich",
+		"Failed":                            "Fehlgeschlagen",
+		"Started":                           "Gestartet",
+		"Aborted":                           "Abgebrochen",
+		"Fixed":                             "Behoben",
+		"Broke":                             "Kaputt",
+		"Errored":                           "Fehler",
+		"Job":                               "Job",
+		"Build":                             "Build",
+		"One-off build":                     "Einmaliger Build",
+		"One-off build #%s":                 "Einmaliger Build #%s",
+		"one-off build %s":                  "einmaliger Build %s",
+		"Resource check":                    "Ressourcenprüfung",
+		"Resource check of %s #%s":          "Ressourcenprüfung von %s #%s",
+		"resource check %s/%s":              "Ressourcenprüfung %s/%s",
+		"Instance vars":                     "Instanzvariablen",
+		"Failed step":                       "Fehlgeschlagener Schritt",
+		"Log":                               "Log",
+		"Tests":                             "Tests",
+		"Failed tests":                      "Fehlgeschlagene Tests",
+		"All %d tests passed":               "Alle %d Tests bestanden",
+		"%d of %d tests failed":             "%d von %d Tests fehlgeschlagen",
+		"(%d skipped)":                      "(%d übersprungen)",
+		"…and %s":                           "…und %s",
+		"%d more":                           "%d weitere",
+		"Full text of %s":                   "Volltext von %s",
+		"…(continued in thread)":            "…(Fortsetzung im Thread)",
+		"…(truncated, full text in thread)": "…(gekürzt, Volltext im Thread)",
+		"…(truncated, %s)":                  "…(gekürzt, %s)",
+		"see build":                         "siehe Build",
+		"Summary":                           "Zusammenfassung",
+		"Pipeline":                          "Pipeline",
+		"no builds":                         "keine Builds",
+		"succeeded":                         "erfolgreich",
+		"pending":                           "ausstehend",
+		"started":                           "gestartet",
+		"aborted":                           "abgebrochen",
+		"failed":                            "fehlgeschlagen",
+		"errored":                           "Fehler",
+		"(paused)":                          "(pausiert)",
+		"CI report: %s":                     "CI-Bericht: %s",
+		"Failing":                           "Fehlschlagend",
+		"Paused":                            "Pausiert",
+		"Long running":                      "Lang laufend",
+		"All jobs are healthy.":             "Alle Jobs sind in Ordnung.",
+		"running for %s":                    "läuft seit %s",
+		"(pipeline)":                        "(Pipeline)",
+		"%s -- %d failing, %d paused, %d long running": "%s -- %d fehlschlagend, %d pausiert, %d lang laufend",
+		"%dh": "%d Std.",
+		"%dm": "%d Min.",
+	},
+	"es": {
+		"Success":                           "Éxito",
+		"Failed":                            "Fallido",
+		"Started":                           "Iniciado",
+		"Aborted":                           "Cancelado",
+		"Fixed":                             "Arreglado",
+		"Broke":                             "Roto",
+		"Errored":                           "Error",
+		"Job":                               "Trabajo",
+		"Build":                             "Ejecución",
+		"One-off build":                     "Ejecución puntual",
+		"One-off build #%s":                 "Ejecución puntual #%s",
+		"one-off build %s":                  "ejecución puntual %s",
+		"Resource check":                    "Comprobación de recurso",
+		"Resource check of %s #%s":          "Comprobación de recurso de %s #%s",
+		"resource check %s/%s":              "comprobación de recurso %s/%s",
+		"Instance vars":                     "Variables de instancia",
+		"Failed step":                       "Paso fallido",
+		"Log":                               "Registro",
+		"Tests":                             "Pruebas",
+		"Failed tests":                      "Pruebas fallidas",
+		"All %d tests passed":               "Las %d pruebas pasaron",
+		"%d of %d tests failed":             "%d de %d pruebas fallaron",
+		"(%d skipped)":                      "(%d omitidas)",
+		"…and %s":                           "…y %s",
+		"%d more":                           "%d más",
+		"Full text of %s":                   "Texto completo de %s",
+		"…(continued in thread)":            "…(continúa en el hilo)",
+		"…(truncated, full text in thread)": "…(truncado, texto completo en el hilo)",
+		"…(truncated, %s)":                  "…(truncado, %s)",
+		"see build":                         "ver ejecución",
+		"Summary":                           "Resumen",
+		"Pipeline":                          "Pipeline",
+		"no builds":                         "sin ejecuciones",
+		"succeeded":                         "exitoso",
+		"pending":                           "pendiente",
+		"started":                           "iniciado",
+		"aborted":                           "cancelado",
+		"failed":                            "fallido",
+		"errored":                           "error",
+		"(paused)":                          "(pausado)",
+		"CI report: %s":                     "Informe de CI: %s",
+		"Failing":                           "Fallando",
+		"Paused":                            "Pausados",
+		"Long running":                      "De larga duración",
+		"All jobs are healthy.":             "Todos los trabajos están bien.",
+		"running for %s":                    "en ejecución desde hace %s",
+		"(pipeline)":                        "(pipeline)",
+		"%s -- %d failing, %d paused, %d long running": "%s -- %d fallando, %d pausados, %d de larga duración",
+		"%dh": "%d h",
+		"%dm": "%d min",
+	},
+	"fr": {
+		"Success":                           "Succès",
+		"Failed":                            "Échec",
+		"Started":                           "Démarré",
+		"Aborted":                           "Interrompu",
+		"Fixed":                             "Corrigé",
+		"Broke":                             "Cassé",
+		"Errored":                           "Erreur",
+		"Job":                               "Job",
+		"Build":                             "Build",
+		"One-off build":                     "Build ponctuel",
+		"One-off build #%s":                 "Build ponctuel #%s",
+		"one-off build %s":                  "build ponctuel %s",
+		"Resource check":                    "Vérification de ressource",
+		"Resource check of %s #%s":          "Vérification de ressource de %s #%s",
+		"resource check %s/%s":              "vérification de ressource %s/%s",
+		"Instance vars":                     "Variables d'instance",
+		"Failed step":                       "Étape en échec",
+		"Log":                               "Journal",
+		"Tests":                             "Tests",
+		"Failed tests":                      "Tests en échec",
+		"All %d tests passed":               "Les %d tests ont réussi",
+		"%d of %d tests failed":             "%d tests sur %d en échec",
+		"(%d skipped)":                      "(%d ignorés)",
+		"…and %s":                           "…et %s",
+		"%d more":                           "%d de plus",
+		"Full text of %s":                   "Texte complet de %s",
+		"…(continued in thread)":            "…(suite dans le fil)",
+		"…(truncated, full text in thread)": "…(tronqué, texte complet dans le fil)",
+		"…(truncated, %s)":                  "…(tronqué, %s)",
+		"see build":                         "voir le build",
+		"Summary":                           "Résumé",
+		"Pipeline":                          "Pipeline",
+		"no builds":                         "aucun build",
+		"succeeded":                         "réussi",
+		"pending":                           "en attente",
+		"started":                           "démarré",
+		"aborted":                           "interrompu",
+		"failed":                            "échoué",
+		"errored":                           "erreur",
+		"(paused)":                          "(en pause)",
+		"CI report: %s":                     "Rapport CI : %s",
+		"Failing":                           "En échec",
+		"Paused":                            "En pause",
+		"Long running":                      "Longue durée",
+		"All jobs are healthy.":             "Tous les jobs sont sains.",
+		"running for %s":                    "en cours depuis %s",
+		"(pipeline)":                        "(pipeline)",
+		"%s -- %d failing, %d paused, %d long running": "%s -- %d en échec, %d en pause, %d de longue durée",
+		"%dh": "%d h",
+		"%dm": "%d min",
+	},
+	"ja": {
+		"Success":                           "成功",
+		"Failed":                            "失敗",
+		"Started":                           "開始",
+		"Aborted":                           "中止",
+		"Fixed":                             "復旧",
+		"Broke":                             "破損",
+		"Errored":                           "エラー",
+		"Job":                               "ジョブ",
+		"Build":                             "ビルド",
+		"One-off build":                     "単発ビルド",
+		"One-off build #%s":                 "単発ビルド #%s",
+		"one-off build %s":                  "単発ビルド %s",
+		"Resource check":                    "リソースチェック",
+		"Resource check of %s #%s":          "%s のリソースチェック #%s",
+		"resource check %s/%s":              "リソースチェック %s/%s",
+		"Instance vars":                     "インスタンス変数",
+		"Failed step":                       "失敗したステップ",
+		"Log":                               "ログ",
+		"Tests":                             "テスト",
+		"Failed tests":                      "失敗したテスト",
+		"All %d tests passed":               "%d 件のテストがすべて成功",
+		"%d of %d tests failed":             "%[2]d 件中 %[1]d 件のテストが失敗",
+		"(%d skipped)":                      "(%d 件スキップ)",
+		"…and %s":                           "…他 %s",
+		"%d more":                           "%d 件",
+		"Full text of %s":                   "%s の全文",
+		"…(continued in thread)":            "…(スレッドに続く)",
+		"…(truncated, full text in thread)": "…(省略、全文はスレッド)",
+		"…(truncated, %s)":                  "…(省略、%s)",
+		"see build":                         "ビルドを見る",
+		"Summary":                           "概要",
+		"Pipeline":                          "パイプライン",
+		"no builds":                         "ビルドなし",
+		"succeeded":                         "成功",
+		"pending":                           "保留中",
+		"started":                           "実行中",
+		"aborted":                           "中止",
+		"failed":                            "失敗",
+		"errored":                           "エラー",
+		"(paused)":                          "(一時停止中)",
+		"CI report: %s":                     "CI レポート: %s",
+		"Failing":                           "失敗中",
+		"Paused":                            "一時停止中",
+		"Long running":                      "長時間実行中",
+		"All jobs are healthy.":             "すべてのジョブは正常です。",
+		"running for %s":                    "%s 実行中",
+		"(pipeline)":                        "(パイプライン)",
+		"%s -- %d failing, %d paused, %d long running": "%s -- 失敗 %d、一時停止 %d、長時間実行 %d",
+		"%dh": "%d時間",
+		"%dm": "%d分",
+	},
+	"pt": {
+		"Success":                           "Sucesso",
+		"Failed":                            "Falhou",
+		"Started":                           "Iniciado",
+		"Aborted":                           "Abortado",
+		"Fixed":                             "Corrigido",
+		"Broke":                             "Quebrou",
+		"Errored":                           "Erro",
+		"Job":                               "Job",
+		"Build":                             "Build",
+		"One-off build":                     "Build avulso",
+		"One-off build #%s":                 "Build avulso #%s",
+		"one-off build %s":                  "build avulso %s",
+		"Resource check":                    "Verificação de recurso",
+		"Resource check of %s #%s":          "Verificação de recurso de %s #%s",
+		"resource check %s/%s":              "verificação de recurso %s/%s",
+		"Instance vars":                     "Variáveis de instância",
+		"Failed step":                       "Etapa com falha",
+		"Log":                               "Log",
+		"Tests":                             "Testes",
+		"Failed tests":                      "Testes com falha",
+		"All %d tests passed":               "Todos os %d testes passaram",
+		"%d of %d tests failed":             "%d de %d testes falharam",
+		"(%d skipped)":                      "(%d ignorados)",
+		"…and %s":                           "…e %s",
+		"%d more":                           "mais %d",
+		"Full text of %s":                   "Texto completo de %s",
+		"…(continued in thread)":            "…(continua na thread)",
+		"…(truncated, full text in thread)": "…(truncado, texto completo na thread)",
+		"…(truncated, %s)":                  "…(truncado, %s)",
+		"see build":                         "ver build",
+		"Summary":                           "Resumo",
+		"Pipeline":                          "Pipeline",
+		"no builds":                         "sem builds",
+		"succeeded":                         "sucesso",
+		"pending":                           "pendente",
+		"started":                           "iniciado",
+		"aborted":                           "abortado",
+		"failed":                            "falhou",
+		"errored":                           "erro",
+		"(paused)":                          "(pausado)",
+		"CI report: %s":                     "Relatório de CI: %s",
+		"Failing":                           "Falhando",
+		"Paused":                            "Pausados",
+		"Long running":                      "Longa duração",
+		"All jobs are healthy.":             "Todos os jobs estão saudáveis.",
+		"running for %s":                    "em execução há %s",
+		"(pipeline)":                        "(pipeline)",
+		"%s -- %d failing, %d paused, %d long running": "%s -- %d falhando, %d pausados, %d de longa duração",
+		"%dh": "%d h",
+		"%dm": "%d min",
+	},
+}
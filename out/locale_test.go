@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+)
+
+func TestLocaleDuration(t *testing.T) {
+	cases := map[string]struct {
+		locale locale
+		d      time.Duration
+		want   string
+	}{
+		"minutes": {
+			d:    5*time.Minute + 20*time.Second,
+			want: "5m",
+		},
+		"hours": {
+			d:    2 * time.Hour,
+			want: "2h",
+		},
+		"hours and minutes": {
+			d:    26*time.Hour + 5*time.Minute,
+			want: "26h 5m",
+		},
+		"zero": {
+			want: "0m",
+		},
+		"localized": {
+			locale: builtinLocales["de"],
+			d:      time.Hour + 30*time.Minute,
+			want:   "1 Std. 30 Min.",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := c.locale.duration(c.d)
+			if got != c.want {
+				t.Fatalf("unexpected string from duration:\n\t(GOT): %#v\n\t(WNT): %#v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestLoadLocale(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "nl.json"), []byte(`{"Failed": "Mislukt", "%d of %d tests failed": "%d van %d tests mislukt"}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`["Mislukt"]`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		source concourse.Source
+		text   string
+		want   string
+		err    bool
+	}{
+		"default": {
+			text: "Failed",
+			want: "Failed",
+		},
+		"built-in": {
+			source: concourse.Source{Locale: "fr"},
+			text:   "Failed",
+			want:   "Échec",
+		},
+		"translations file": {
+			source: concourse.Source{Locale: "fr", TranslationsFile: "nl.json"},
+			text:   "Failed",
+			want:   "Mislukt",
+		},
+		"translations file fallback": {
+			source: concourse.Source{Locale: "fr", TranslationsFile: "nl.json"},
+			text:   "Success",
+			want:   "Succès",
+		},
+		"invalid translations file": {
+			source: concourse.Source{Locale: "fr", TranslationsFile: "bad.json"},
+			text:   "Failed",
+			want:   "Échec",
+		},
+		"missing translations file strict": {
+			source: concourse.Source{TranslationsFile: "missing.json", StrictFiles: true},
+			err:    true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			l, err := loadLocale(c.source, dir)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from loadLocale:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from loadLocale:\n\t(GOT): nil")
+			} else if !c.err {
+				if got := l.text(c.text); got != c.want {
+					t.Fatalf("unexpected text from loadLocale:\n\t(GOT): %#v\n\t(WNT): %#v", got, c.want)
+				}
+			}
+		})
+	}
+
+	// Translations must not be added to the built-in locale.
+	if _, err := loadLocale(concourse.Source{Locale: "fr", TranslationsFile: "nl.json"}, dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := builtinLocales["fr"]["Failed"]; got != "Échec" {
+		t.Fatalf("unexpected built-in translation after loadLocale:\n\t(GOT): %#v\n\t(WNT): %#v", got, "Échec")
+	}
+}
+
+func TestBuiltinLocales(t *testing.T) {
+	// Every built-in locale translates the same strings, so that no string
+	// is left in English.
+	for name, l := range builtinLocales {
+		if name == "en" {
+			continue
+		}
+		for k := range builtinLocales["de"] {
+			if _, ok := l[k]; !ok {
+				t.Errorf("missing translation of %q in locale %q", k, name)
+			}
+		}
+		if len(l) != len(builtinLocales["de"]) {
+			t.Errorf("unexpected number of translations in locale %q:\n\t(GOT): %d\n\t(WNT): %d", name, len(l), len(builtinLocales["de"]))
+		}
+	}
+
+	got := builtinLocales["ja"].format("%d of %d tests failed", 3, 840)
+	if want := "840 件中 3 件のテストが失敗"; got != want {
+		t.Fatalf("unexpected text from format:\n\t(GOT): %#v\n\t(WNT): %#v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+// defaultTextLimit is the number of characters of text shown in an alert.
+const defaultTextLimit = 3000
+
+// longTextModes are the ways text over the limit is handled.
+var longTextModes = []string{"truncate", "thread", "upload"}
+
+// renderText formats the text of the message's attachment and shortens it
+// to the limit of the alert. Depending on the mode, the rest of the text is
+// dropped, posted as replies or uploaded as a snippet. Secrets are redacted
+// from the whole text first, as a secret split by the limit is not matched.
+func renderText(message *slack.Message, alert Alert, m concourse.BuildMetadata, r *redactor) {
+	a := &message.Attachments[0]
+	if a.Text == "" {
+		return
+	}
+	if r != nil {
+		a.Text = r.redact(a.Text)
+	}
+	if alert.TextFormat != "plain" {
+		a.MrkdwnIn = []string{"text"}
+	}
+
+	limit := alert.TextLimit
+	if limit <= 0 {
+		limit = defaultTextLimit
+	}
+	if utf8.RuneCountInString(a.Text) <= limit {
+		a.Text = formatText(a.Text, alert.TextFormat)
+		return
+	}
+
+	text := a.Text
+	chunks := splitText(text, limit)
+	a.Text = formatText(chunks[0], alert.TextFormat)
+	switch alert.LongText {
+	case "thread":
+		a.Text += "\n" + alert.Locale.text("…(continued in thread)")
+		for _, c := range chunks[1:] {
+			message.Replies = append(message.Replies, slack.Message{Text: formatText(c, alert.TextFormat)})
+		}
+	case "upload":
+		message.Files = append(message.Files, slack.File{
+			Filename: "text.txt",
+			Title:    alert.Locale.format("Full text of %s", m.URL),
+			Content:  []byte(text),
+			Snippet:  true,
+		})
+		a.Text += "\n" + alert.Locale.text("…(truncated, full text in thread)")
+	default:
+		a.Text += "\n" + alert.Locale.format("…(truncated, %s)", link(m.URL, alert.Locale.text("see build")))
+	}
+}
+
+// splitText splits the text into chunks of at most limit characters.
+// Chunks end at a line break where possible.
+func splitText(text string, limit int) []string {
+	var chunks []string
+	r := []rune(text)
+	for len(r) > limit {
+		chunk := string(r[:limit])
+		end := limit
+		// Only break at a line that leaves a reasonably full chunk.
+		if i := strings.LastIndex(chunk, "\n"); i > 0 && r[limit] != '\n' {
+			if n := utf8.RuneCountInString(chunk[:i]); n >= limit/2 {
+				end = n
+			}
+		}
+		chunks = append(chunks, strings.TrimRight(string(r[:end]), "\n"))
+		r = []rune(strings.TrimLeft(string(r[end:]), "\n"))
+	}
+	if len(r) > 0 {
+		chunks = append(chunks, string(r))
+	}
+	return chunks
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -15,69 +19,205 @@ import (
 	"github.com/arbourd/concourse-slack-alert-resource/slack"
 )
 
-func buildMessage(alert Alert, m concourse.BuildMetadata, path string) *slack.Message {
-	message := alert.Message
-	channel := alert.Channel
-	text := alert.Text
-
-	// Open and read message file if set
-	if alert.MessageFile != "" {
-		file := filepath.Join(path, alert.MessageFile)
-		f, err := os.ReadFile(file)
-
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error reading message_file: %v\nwill default to message instead\n", err)
-		} else {
-			message = strings.TrimSpace(string(f))
-		}
+func buildMessage(alert Alert, m concourse.BuildMetadata, path string, r *redactor) (*slack.Message, error) {
+	message, err := readFileParam("message_file", path, alert.MessageFile, alert.Message, alert.StrictFiles)
+	if err != nil {
+		return nil, err
 	}
 
-	// Open and read channel file if set
-	if alert.ChannelFile != "" {
-		file := filepath.Join(path, alert.ChannelFile)
-		f, err := os.ReadFile(file)
+	channel, err := readFileParam("channel_file", path, alert.ChannelFile, alert.Channel, alert.StrictFiles)
+	if err != nil {
+		return nil, err
+	}
 
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error reading channel_file: %v\nwill default to channel instead\n", err)
-		} else {
-			channel = strings.TrimSpace(string(f))
-		}
+	text, err := readFileParam("text_file", path, alert.TextFile, alert.Text, alert.StrictFiles)
+	if err != nil {
+		return nil, err
 	}
 
-	// Open and read text file if set
-	if alert.TextFile != "" {
-		file := filepath.Join(path, alert.TextFile)
-		f, err := os.ReadFile(file)
+	author := escape(message)
+	if alert.Emoji != "" {
+		author = strings.TrimSpace(fmt.Sprintf("%s %s", alert.Emoji, author))
+	}
 
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error reading text_file: %v\nwill default to text instead\n", err)
-		} else {
-			text = strings.TrimSpace(string(f))
-		}
+	l := alert.Locale
+	summary := fmt.Sprintf("%s/%s/%s", m.PipelineName, m.JobName, m.BuildName)
+	title := fmt.Sprintf("%s/%s #%s", m.PipelineName, m.JobName, m.BuildName)
+	fields := []slack.Field{
+		{
+			Title: l.text("Job"),
+			Value: fmt.Sprintf("%s/%s", link(m.PipelineURL, escape(m.PipelineName)), link(m.JobURL, escape(m.JobName))),
+			Short: true,
+		},
+		{
+			Title: l.text("Build"),
+			Value: link(m.URL, escape(m.BuildName)),
+			Short: true,
+		},
 	}
 
-	attachment := slack.Attachment{
-		Fallback:   fmt.Sprintf("%s -- %s", fmt.Sprintf("%s: %s/%s/%s", message, m.PipelineName, m.JobName, m.BuildName), m.URL),
-		AuthorName: message,
-		Color:      alert.Color,
-		Footer:     m.URL,
-		FooterIcon: alert.IconURL,
-		Fields: []slack.Field{
+	switch m.Kind {
+	case concourse.KindOneOff:
+		summary = l.format("one-off build %s", m.BuildName)
+		title = l.format("One-off build #%s", m.BuildName)
+		fields = []slack.Field{
 			{
-				Title: "Job",
-				Value: fmt.Sprintf("%s/%s", m.PipelineName, m.JobName),
+				Title: l.text("One-off build"),
+				Value: link(m.URL, escape(m.BuildName)),
 				Short: true,
 			},
+		}
+	case concourse.KindCheck:
+		summary = l.format("resource check %s/%s", m.PipelineName, m.BuildName)
+		title = l.format("Resource check of %s #%s", m.PipelineName, m.BuildName)
+		fields = []slack.Field{
 			{
-				Title: "Build",
-				Value: m.BuildName,
+				Title: l.text("Resource check"),
+				Value: link(m.PipelineURL, escape(m.PipelineName)),
 				Short: true,
 			},
-		},
-		Text: text,
+			{
+				Title: l.text("Build"),
+				Value: link(m.URL, escape(m.BuildName)),
+				Short: true,
+			},
+		}
+	}
+
+	attachment := slack.Attachment{
+		Fallback:   escape(fmt.Sprintf("%s: %s -- %s", message, summary, m.URL)),
+		AuthorName: author,
+		Title:      escape(title),
+		TitleLink:  m.URL,
+		Color:      alert.Color,
+		Footer:     m.URL,
+		FooterIcon: alert.IconURL,
+		Fields:     fields,
+		Text:       text,
+	}
+	if len(m.InstanceVars) > 0 {
+		attachment.Fields = append(attachment.Fields, slack.Field{Title: l.text("Instance vars"), Value: formatInstanceVars(m.InstanceVars), Short: true})
+	}
+	attachment.Fields = append(attachment.Fields, alert.Fields...)
+
+	msg := &slack.Message{Text: formatMentions(alert.Mentions), Attachments: []slack.Attachment{attachment}, Channel: channel}
+	renderText(msg, alert, m, r)
+	return msg, nil
+}
+
+// formatInstanceVars formats instance vars as readable `key: value` pairs,
+// sorted by key. Values that are not strings are formatted as JSON.
+func formatInstanceVars(vars map[string]any) string {
+	var pairs []string
+	for _, k := range slices.Sorted(maps.Keys(vars)) {
+		v, ok := vars[k].(string)
+		if !ok {
+			b, _ := json.Marshal(vars[k])
+			v = string(b)
+		}
+		pairs = append(pairs, fmt.Sprintf("%s: %s", escape(k), escape(v)))
+	}
+	return strings.Join(pairs, "\n")
+}
+
+// escape escapes the control characters of Slack's mrkdwn in the text.
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// formatMentions formats user IDs, user group IDs and special mentions like
+// "here" so that they notify in Slack.
+func formatMentions(mentions []string) string {
+	formatted := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		m = strings.TrimPrefix(strings.TrimSpace(m), "@")
+		switch {
+		case m == "":
+			continue
+		case strings.HasPrefix(m, "<"):
+			formatted = append(formatted, m)
+		case m == "here" || m == "channel" || m == "everyone":
+			formatted = append(formatted, fmt.Sprintf("<!%s>", m))
+		case strings.HasPrefix(m, "S"):
+			formatted = append(formatted, fmt.Sprintf("<!subteam^%s>", m))
+		default:
+			formatted = append(formatted, fmt.Sprintf("<@%s>", m))
+		}
+	}
+	return strings.Join(formatted, " ")
+}
+
+// readFileParam returns the trimmed contents of a _file param, read relative
+// to the build directory. If the file cannot be read, the fallback value is
+// returned instead, unless strict is set.
+func readFileParam(param, path, file, fallback string, strict bool) (string, error) {
+	if file == "" {
+		return fallback, nil
+	}
+
+	contents, err := readBuildFile(path, file)
+	if err == nil && contents == "" && strict {
+		err = errors.New("file is empty")
+	}
+
+	if err != nil {
+		if strict {
+			return "", fmt.Errorf("error reading %s: %w", param, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "error reading %s: %v\nwill default to %s instead\n", param, err, strings.TrimSuffix(param, "_file"))
+		return fallback, nil
+	}
+	return contents, nil
+}
+
+// readBuildFile reads the file from the build directory. Files outside of the
+// build directory, including through symlinks, cannot be read.
+func readBuildFile(path, file string) (string, error) {
+	root, err := os.OpenRoot(path)
+	if err != nil {
+		return "", err
+	}
+	defer root.Close()
+
+	f, err := root.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return "", err
 	}
+	return strings.TrimSpace(string(f)), nil
+}
 
-	return &slack.Message{Attachments: []slack.Attachment{attachment}, Channel: channel}
+// globBuildFiles returns the unique files of fsys matching the glob patterns,
+// in order. Patterns that fail or match no files are passed to skip, which
+// returns an error to stop.
+func globBuildFiles(fsys fs.FS, patterns []string, skip func(pattern string, err error) error) ([]string, error) {
+	var names []string
+	for _, p := range patterns {
+		matches, err := fs.Glob(fsys, path.Clean(p))
+		if err == nil && len(matches) == 0 {
+			err = errors.New("no files match")
+		}
+		if err != nil {
+			if err := skip(p, err); err != nil {
+				return nil, err
+			}
+		}
+		for _, m := range matches {
+			if !slices.Contains(names, m) {
+				names = append(names, m)
+			}
+		}
+	}
+	return names, nil
+}
+
+// newClient returns a Concourse Client for the build's team.
+func newClient(input *concourse.OutRequest, m concourse.BuildMetadata) (*concourse.Client, error) {
+	c, err := concourse.NewClient(m.Host, m.TeamName, input.Source.Username, input.Source.Password)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to Concourse: %w", err)
+	}
+	return c, nil
 }
 
 func previousBuildStatus(input *concourse.OutRequest, m concourse.BuildMetadata) (string, error) {
@@ -86,9 +226,14 @@ func previousBuildStatus(input *concourse.OutRequest, m concourse.BuildMetadata)
 		return "", nil
 	}
 
-	c, err := concourse.NewClient(m.Host, m.TeamName, input.Source.Username, input.Source.Password)
+	// Exit early if the build has no job, and so no previous build
+	if m.Kind == concourse.KindOneOff || m.Kind == concourse.KindCheck {
+		return "", nil
+	}
+
+	c, err := newClient(input, m)
 	if err != nil {
-		return "", fmt.Errorf("error connecting to Concourse: %w", err)
+		return "", err
 	}
 
 	p, err := previousBuildName(m.BuildName)
@@ -96,13 +241,7 @@ func previousBuildStatus(input *concourse.OutRequest, m concourse.BuildMetadata)
 		return "", fmt.Errorf("error parsing build name: %w", err)
 	}
 
-	instanceVars := ""
-	instanceVarsIndex := strings.Index(m.URL, "?")
-	if instanceVarsIndex > -1 {
-		instanceVars = m.URL[instanceVarsIndex:]
-	}
-
-	previous, err := c.JobBuild(m.PipelineName, m.JobName, p, instanceVars)
+	previous, err := c.JobBuild(m.PipelineName, m.JobName, p, m.InstanceVars)
 	if err != nil {
 		return "", fmt.Errorf("error requesting Concourse build status: %w", err)
 	}
@@ -131,19 +270,103 @@ func previousBuildName(s string) (string, error) {
 	return strings.Trim(s, ".0"), nil
 }
 
+// buildFailureFields returns fields describing the failed step of the build.
+func buildFailureFields(input *concourse.OutRequest, m concourse.BuildMetadata, l locale) ([]slack.Field, error) {
+	c, err := newClient(input, m)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := newRedactor(input.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := input.Params.LogLines
+	if lines <= 0 {
+		lines = defaultLogLines
+	}
+	return failedStepFields(c, m.ID, lines, l, r)
+}
+
+// buildInputFields returns fields describing the versions of the build's inputs.
+func buildInputFields(input *concourse.OutRequest, m concourse.BuildMetadata) ([]slack.Field, error) {
+	c, err := newClient(input, m)
+	if err != nil {
+		return nil, err
+	}
+	return inputFields(c, m, input.Source.InputURLs)
+}
+
+// digestMessage returns a message summarizing the jobs of the build's pipeline.
+func digestMessage(input *concourse.OutRequest, alert Alert, m concourse.BuildMetadata) (*slack.Message, error) {
+	if m.PipelineName == "" {
+		return nil, errors.New("digest requires a pipeline, not a one-off build")
+	}
+
+	c, err := newClient(input, m)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := c.Jobs(m.PipelineName, m.InstanceVars)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting Concourse jobs: %w", err)
+	}
+	return buildDigest(input, alert, jobs, m), nil
+}
+
 var maxElapsedTime = 30 * time.Second
 
+// retryPolicy returns the Slack retry policy configured by the source.
+func retryPolicy(r concourse.Retry) slack.Retry {
+	policy := slack.Retry{
+		MaxElapsedTime:  time.Duration(r.MaxElapsed),
+		InitialInterval: time.Duration(r.InitialInterval),
+		MaxInterval:     time.Duration(r.MaxInterval),
+		MaxAttempts:     r.MaxAttempts,
+	}
+	if policy.MaxElapsedTime == 0 {
+		policy.MaxElapsedTime = maxElapsedTime
+	}
+	return policy
+}
+
 func out(input *concourse.OutRequest, path string) (*concourse.OutResponse, error) {
-	if input.Source.URL == "" {
-		return nil, errors.New("slack webhook url cannot be blank")
+	if input.Source.URL == "" && input.Source.Token == "" {
+		return nil, errors.New("slack webhook url or token cannot be blank")
 	}
 
-	alert := NewAlert(input)
 	metadata := concourse.NewBuildMetadata(input.Source.ConcourseURL)
+	l, err := loadLocale(input.Source, path)
+	if err != nil {
+		return nil, err
+	}
+	alert := NewAlert(input, metadata, l)
 	if alert.Disabled {
 		return buildOut(alert.Type, alert.Channel, false), nil
 	}
 
+	if input.Params.CancelScheduled != "" || input.Params.CancelScheduledFile != "" {
+		return cancelScheduled(input, alert, path)
+	}
+
+	if input.Params.Report != nil {
+		message, err := teamReport(input, alert, metadata)
+		if err != nil {
+			return nil, err
+		}
+		return send(input, "report", message)
+	}
+
+	if input.Params.Digest {
+		message, err := digestMessage(input, alert, metadata)
+		if err != nil {
+			return nil, err
+		}
+		return send(input, "digest", message)
+	}
+
 	if alert.Type == "fixed" || alert.Type == "broke" {
 		pstatus, err := previousBuildStatus(input, metadata)
 		if err != nil {
@@ -155,12 +378,231 @@ func out(input *concourse.OutRequest, path string) (*concourse.OutResponse, erro
 		}
 	}
 
-	message := buildMessage(alert, metadata, path)
-	err := slack.Send(input.Source.URL, message, maxElapsedTime)
+	if input.Params.ShowFailedStep {
+		fields, err := buildFailureFields(input, metadata, alert.Locale)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error getting failed step: %v\n", err)
+		}
+		alert.Fields = append(alert.Fields, fields...)
+	}
+
+	if input.Params.ShowInputs {
+		fields, err := buildInputFields(input, metadata)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error getting build inputs: %v\n", err)
+		}
+		alert.Fields = append(alert.Fields, fields...)
+	}
+
+	if len(input.Params.JUnitReport) > 0 {
+		r, err := readTestReports(path, input.Params.JUnitReport, alert.StrictFiles)
+		if err != nil {
+			return nil, err
+		}
+		alert.Fields = append(alert.Fields, testReportFields(r, input.Params.MaxFailedTests, metadata, alert.Locale)...)
+	}
+
+	r, err := newRedactor(input.Source)
+	if err != nil {
+		return nil, err
+	}
+	message, err := buildMessage(alert, metadata, path, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(input.Params.Attachments) > 0 {
+		files, err := readAttachments(path, input.Params, alert.StrictFiles)
+		if err != nil {
+			return nil, err
+		}
+		message.Files = append(message.Files, files...)
+	}
+
+	if key := input.Params.AggregateKey; key != "" {
+		if input.Source.Token == "" {
+			return nil, errors.New("aggregate_key requires a slack token")
+		}
+		message.Metadata = aggregateMetadata(fmt.Sprintf("%s/%s", metadata.ID, key))
+	}
+
+	dmUsers, ephemeralUser, err := directRecipients(input, alert, path)
+	if err != nil {
+		return nil, err
+	}
+	if input.Params.SkipChannel && len(dmUsers) == 0 && ephemeralUser == "" {
+		return nil, errors.New("skip_channel is set, but no dm_users or ephemeral_user to send the message to")
+	}
+
+	var o *concourse.OutResponse
+	if !input.Params.SkipChannel {
+		o, err = sendChannels(input, alert, message)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(dmUsers) > 0 || ephemeralUser != "" {
+		do, err := sendDirect(input, alert.Type, message, dmUsers, ephemeralUser)
+		if err != nil {
+			return nil, err
+		}
+		// The response of the channel is kept if it was sent or dry run,
+		// so that its payload is reported.
+		if o == nil || slices.ContainsFunc(o.Metadata, func(m concourse.Metadata) bool { return m.Name == "error" }) {
+			o = do
+		} else {
+			// Add the recipients to the response of the channel message.
+			for _, md := range do.Metadata {
+				if !slices.ContainsFunc(o.Metadata, func(m concourse.Metadata) bool { return m.Name == md.Name }) {
+					o.Metadata = append(o.Metadata, md)
+				}
+			}
+		}
+	}
+	return o, nil
+}
+
+// sendChannels sends the message to its channel, and copies of it to the
+// further channels of the route. The response reports all channels, and any
+// failure.
+func sendChannels(input *concourse.OutRequest, alert Alert, message *slack.Message) (*concourse.OutResponse, error) {
+	o, err := send(input, alert.Type, message)
+	if err != nil || len(alert.Channels) == 0 {
+		return o, err
+	}
+
+	channels := []string{message.Channel}
+	for _, ch := range alert.Channels {
+		m := *message
+		m.Channel = ch
+		co, err := send(input, alert.Type, &m)
+		if err != nil {
+			return nil, err
+		}
+		if !alerted(co) {
+			o = co
+		}
+		channels = append(channels, ch)
+	}
+	for i, md := range o.Metadata {
+		if md.Name == "channel" {
+			o.Metadata[i].Value = strings.Join(channels, ",")
+		}
+	}
+	return o, nil
+}
+
+// alerted returns true if the response reports that the message was sent.
+func alerted(o *concourse.OutResponse) bool {
+	return !slices.Contains(o.Metadata, concourse.Metadata{Name: "alerted", Value: "false"})
+}
+
+// send sends the message to Slack and returns the response of the out
+// operation.
+func send(input *concourse.OutRequest, atype string, message *slack.Message) (*concourse.OutResponse, error) {
+	if err := prepare(input, message); err != nil {
+		return nil, err
+	}
+
+	if input.Params.DryRun || input.Source.DryRun {
+		return dryRun(atype, message)
+	}
+
+	if input.Params.PostAt != "" {
+		return schedule(input, atype, message)
+	}
+
+	if err := deliver(input, message); err != nil {
+		return failed(input, atype, message.Channel, fmt.Errorf("error sending slack message: %w", err))
+	}
+	return buildOut(atype, message.Channel, true), nil
+}
+
+// prepare sets the sender of the message and redacts it.
+func prepare(input *concourse.OutRequest, message *slack.Message) error {
+	setSender(message, input)
+
+	r, err := newRedactor(input.Source)
+	if err != nil {
+		return err
+	}
+	r.redactMessage(message)
+	return nil
+}
+
+// failed returns the error, or reports it in the response without failing
+// the build if fail_on_error is false.
+func failed(input *concourse.OutRequest, atype, channel string, err error) (*concourse.OutResponse, error) {
+	if input.Source.FailOnError == nil || *input.Source.FailOnError {
+		return nil, err
+	}
+
+	fmt.Fprintln(os.Stderr, err)
+	o := buildOut(atype, channel, false)
+	o.Metadata = append(o.Metadata, concourse.Metadata{Name: "error", Value: err.Error()})
+	return o, nil
+}
+
+// setSender sets the name and icon that the message is posted as. The params
+// override the sender of the source, and an icon of the params replaces both
+// icons of the source.
+func setSender(message *slack.Message, input *concourse.OutRequest) {
+	s := input.Source.Sender
+	if input.Params.Username != "" {
+		s.Username = input.Params.Username
+	}
+	if input.Params.IconURL != "" || input.Params.IconEmoji != "" {
+		s.IconURL, s.IconEmoji = input.Params.IconURL, input.Params.IconEmoji
+	}
+
+	message.Username = s.Username
+	message.IconURL = s.IconURL
+	message.IconEmoji = ""
+	if e := strings.Trim(s.IconEmoji, ":"); e != "" {
+		message.IconEmoji = fmt.Sprintf(":%s:", e)
+	}
+}
+
+// dryRun writes the message to stderr and the metadata instead of sending it.
+func dryRun(atype string, message *slack.Message) (*concourse.OutResponse, error) {
+	b, err := json.Marshal(message)
 	if err != nil {
-		return nil, fmt.Errorf("error sending slack message: %w", err)
+		return nil, fmt.Errorf("error encoding slack message: %w", err)
 	}
-	return buildOut(alert.Type, message.Channel, true), nil
+	fmt.Fprintf(os.Stderr, "dry run, not sending slack message:\n%s\n", b)
+	for _, r := range message.Replies {
+		fmt.Fprintf(os.Stderr, "reply in thread:\n%s\n", r.Text)
+	}
+	for _, f := range message.Files {
+		if f.Snippet {
+			fmt.Fprintf(os.Stderr, "snippet %q:\n%s\n", f.Title, f.Content)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "file %q (%d bytes)\n", f.Filename, len(f.Content))
+	}
+
+	o := buildOut(atype, message.Channel, false)
+	o.Metadata = append(o.Metadata, concourse.Metadata{Name: "payload", Value: string(b)})
+	return o, nil
+}
+
+// deliver sends the message with the webhook, or with the Web API if the
+// message is aggregated, has a thread or there is no webhook.
+func deliver(input *concourse.OutRequest, message *slack.Message) error {
+	retry := retryPolicy(input.Source.Retry)
+	thread := len(message.Replies) > 0 || len(message.Files) > 0
+	if input.Source.Token == "" || (input.Source.URL != "" && message.Metadata == nil && !thread) {
+		if thread {
+			return errors.New("replies and files require a slack token")
+		}
+		return slack.Send(input.Source.URL, message, retry)
+	}
+
+	c := slack.NewClient(input.Source.Token, retry)
+	if message.Metadata != nil {
+		return aggregate(c, message)
+	}
+	return c.Send(message)
 }
 
 func buildOut(atype string, channel string, alerted bool) *concourse.OutResponse {
@@ -175,11 +617,18 @@ func buildOut(atype string, channel string, alerted bool) *concourse.OutResponse
 }
 
 func main() {
+	// Flags run the out operation locally, instead of in a build.
+	if len(os.Args) > 1 && strings.HasPrefix(os.Args[1], "-") {
+		if err := local(os.Args[1:], os.Stdout); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	// The first argument is the path to the build's sources.
 	path := os.Args[1]
 
-	var input *concourse.OutRequest
-	err := json.NewDecoder(os.Stdin).Decode(&input)
+	input, err := decodeInput(os.Stdin)
 	if err != nil {
 		log.Fatalln(fmt.Errorf("error reading stdin: %w", err))
 	}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/arbourd/concourse-slack-alert-resource/concourse"
 	"github.com/arbourd/concourse-slack-alert-resource/slack"
@@ -158,6 +159,24 @@ func TestOut(t *testing.T) {
 			},
 			env: env,
 		},
+		"route with channels": {
+			outRequest: &concourse.OutRequest{
+				Source: concourse.Source{
+					URL:    ok.URL,
+					Routes: []concourse.Route{{AlertTypes: []string{"failed"}, Channels: []string{"oncall", "ci-alerts"}}},
+				},
+				Params: concourse.OutParams{AlertType: "failed"},
+			},
+			want: &concourse.OutResponse{
+				Version: concourse.Version{"ver": "static"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "failed"},
+					{Name: "channel", Value: "oncall,ci-alerts"},
+					{Name: "alerted", Value: "true"},
+				},
+			},
+			env: env,
+		},
 		"disable alert": {
 			outRequest: &concourse.OutRequest{
 				Source: concourse.Source{URL: bad.URL},
@@ -173,6 +192,25 @@ func TestOut(t *testing.T) {
 			},
 			env: env,
 		},
+		"bad request without fail on error": {
+			outRequest: &concourse.OutRequest{
+				Source: concourse.Source{
+					URL:         bad.URL,
+					Retry:       concourse.Retry{MaxAttempts: 1},
+					FailOnError: new(bool),
+				},
+			},
+			want: &concourse.OutResponse{
+				Version: concourse.Version{"ver": "static"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "default"},
+					{Name: "channel", Value: ""},
+					{Name: "alerted", Value: "false"},
+					{Name: "error", Value: "error sending slack message: unexpected response status code: 404"},
+				},
+			},
+			env: env,
+		},
 		"error without Slack URL": {
 			outRequest: &concourse.OutRequest{
 				Source: concourse.Source{URL: ""},
@@ -187,6 +225,13 @@ func TestOut(t *testing.T) {
 			env: env,
 			err: true,
 		},
+		"error with bad request and max attempts": {
+			outRequest: &concourse.OutRequest{
+				Source: concourse.Source{URL: bad.URL, Retry: concourse.Retry{MaxAttempts: 2, InitialInterval: concourse.Duration(time.Millisecond)}},
+			},
+			env: env,
+			err: true,
+		},
 		"error without basic auth for fixed type": {
 			outRequest: &concourse.OutRequest{
 				Source: concourse.Source{URL: ok.URL, Username: "", Password: ""},
@@ -216,8 +261,11 @@ func TestOut(t *testing.T) {
 }
 func TestBuildMessage(t *testing.T) {
 	cases := map[string]struct {
-		alert Alert
-		want  *slack.Message
+		alert        Alert
+		instanceVars map[string]any
+		metadata     *concourse.BuildMetadata
+		want         *slack.Message
+		err          bool
 	}{
 		"empty channel": {
 			alert: Alert{
@@ -232,9 +280,11 @@ func TestBuildMessage(t *testing.T) {
 						Fallback:   "Testing: demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						Color:      "#ffffff",
 						AuthorName: "Testing",
+						Title:      "demo/test #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						Fields: []slack.Field{
-							{Title: "Job", Value: "demo/test", Short: true},
-							{Title: "Build", Value: "1", Short: true},
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
 						},
 						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
 				},
@@ -254,9 +304,11 @@ func TestBuildMessage(t *testing.T) {
 						Fallback:   "Testing: demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						Color:      "#ffffff",
 						AuthorName: "Testing",
+						Title:      "demo/test #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						Fields: []slack.Field{
-							{Title: "Job", Value: "demo/test", Short: true},
-							{Title: "Build", Value: "1", Short: true},
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
 						},
 						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
 				},
@@ -273,9 +325,11 @@ func TestBuildMessage(t *testing.T) {
 					{
 						Fallback:   "filecontents: demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						AuthorName: "filecontents",
+						Title:      "demo/test #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						Fields: []slack.Field{
-							{Title: "Job", Value: "demo/test", Short: true},
-							{Title: "Build", Value: "1", Short: true},
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
 						},
 						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
 				},
@@ -292,9 +346,11 @@ func TestBuildMessage(t *testing.T) {
 					{
 						Fallback:   "Testing: demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						AuthorName: "Testing",
+						Title:      "demo/test #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						Fields: []slack.Field{
-							{Title: "Job", Value: "demo/test", Short: true},
-							{Title: "Build", Value: "1", Short: true},
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
 						},
 						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
 				},
@@ -309,10 +365,12 @@ func TestBuildMessage(t *testing.T) {
 			want: &slack.Message{
 				Attachments: []slack.Attachment{
 					{
-						Fallback: ": demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						Fallback:  ": demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						Title:     "demo/test #1",
+						TitleLink: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						Fields: []slack.Field{
-							{Title: "Job", Value: "demo/test", Short: true},
-							{Title: "Build", Value: "1", Short: true},
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
 						},
 						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
 				},
@@ -328,16 +386,239 @@ func TestBuildMessage(t *testing.T) {
 			want: &slack.Message{
 				Attachments: []slack.Attachment{
 					{
-						Fallback: ": demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						Fallback:  ": demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						Title:     "demo/test #1",
+						TitleLink: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
 						Fields: []slack.Field{
-							{Title: "Job", Value: "demo/test", Short: true},
-							{Title: "Build", Value: "1", Short: true},
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
 						},
 						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
 				},
 				Channel: "testchannel",
 			},
 		},
+		"mentions": {
+			alert: Alert{
+				Type:     "default",
+				Message:  "Testing",
+				Mentions: []string{"@here", "U123", "S456", "<@W789>"},
+			},
+			want: &slack.Message{
+				Text: "<!here> <@U123> <!subteam^S456> <@W789>",
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "Testing: demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						AuthorName: "Testing",
+						Title:      "demo/test #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						Fields: []slack.Field{
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
+						},
+						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
+				},
+			},
+		},
+		"emoji": {
+			alert: Alert{
+				Type:    "success",
+				Emoji:   ":white_check_mark:",
+				Message: "Success",
+			},
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "Success: demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						AuthorName: ":white_check_mark: Success",
+						Title:      "demo/test #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						Fields: []slack.Field{
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
+						},
+						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
+				},
+			},
+		},
+		"instance vars": {
+			alert: Alert{
+				Type:    "default",
+				Message: "Testing",
+			},
+			instanceVars: map[string]any{"branch": "main", "pr": float64(12), "args": []any{"start"}},
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "Testing: demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						AuthorName: "Testing",
+						Title:      "demo/test #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						Fields: []slack.Field{
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
+							{Title: "Instance vars", Value: "args: [\"start\"]\nbranch: main\npr: 12", Short: true},
+						},
+						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
+				},
+			},
+		},
+		"special characters": {
+			alert: Alert{
+				Type:    "default",
+				Message: "<!channel> R&D",
+			},
+			metadata: &concourse.BuildMetadata{
+				Kind:         concourse.KindJob,
+				Host:         "https://ci.example.com",
+				TeamName:     "main",
+				PipelineName: "a<b>&c",
+				JobName:      "test|1",
+				BuildName:    "1",
+				URL:          "https://ci.example.com/teams/main/pipelines/a%3Cb%3E&c/jobs/test%7C1/builds/1",
+				PipelineURL:  "https://ci.example.com/teams/main/pipelines/a%3Cb%3E&c",
+				JobURL:       "https://ci.example.com/teams/main/pipelines/a%3Cb%3E&c/jobs/test%7C1",
+			},
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "&lt;!channel&gt; R&amp;D: a&lt;b&gt;&amp;c/test|1/1 -- https://ci.example.com/teams/main/pipelines/a%3Cb%3E&amp;c/jobs/test%7C1/builds/1",
+						AuthorName: "&lt;!channel&gt; R&amp;D",
+						Title:      "a&lt;b&gt;&amp;c/test|1 #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/a%3Cb%3E&c/jobs/test%7C1/builds/1",
+						Fields: []slack.Field{
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/a%3Cb%3E&c|a&lt;b&gt;&amp;c>/<https://ci.example.com/teams/main/pipelines/a%3Cb%3E&c/jobs/test%7C1|test|1>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/a%3Cb%3E&c/jobs/test%7C1/builds/1|1>", Short: true},
+						},
+						Footer: "https://ci.example.com/teams/main/pipelines/a%3Cb%3E&c/jobs/test%7C1/builds/1", FooterIcon: ""},
+				},
+			},
+		},
+		"one-off build": {
+			alert: Alert{
+				Type:    "default",
+				Message: "Testing",
+			},
+			metadata: &concourse.BuildMetadata{
+				Kind:      concourse.KindOneOff,
+				Host:      "https://ci.example.com",
+				ID:        "123",
+				TeamName:  "main",
+				BuildName: "45",
+				URL:       "https://ci.example.com/builds/123",
+			},
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "Testing: one-off build 45 -- https://ci.example.com/builds/123",
+						AuthorName: "Testing",
+						Title:      "One-off build #45",
+						TitleLink:  "https://ci.example.com/builds/123",
+						Fields: []slack.Field{
+							{Title: "One-off build", Value: "<https://ci.example.com/builds/123|45>", Short: true},
+						},
+						Footer: "https://ci.example.com/builds/123", FooterIcon: ""},
+				},
+			},
+		},
+		"resource check build": {
+			alert: Alert{
+				Type:    "default",
+				Message: "Testing",
+			},
+			metadata: &concourse.BuildMetadata{
+				Kind:         concourse.KindCheck,
+				Host:         "https://ci.example.com",
+				ID:           "123",
+				TeamName:     "main",
+				PipelineName: "demo",
+				BuildName:    "check",
+				URL:          "https://ci.example.com/builds/123",
+				PipelineURL:  "https://ci.example.com/teams/main/pipelines/demo",
+			},
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "Testing: resource check demo/check -- https://ci.example.com/builds/123",
+						AuthorName: "Testing",
+						Title:      "Resource check of demo #check",
+						TitleLink:  "https://ci.example.com/builds/123",
+						Fields: []slack.Field{
+							{Title: "Resource check", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/builds/123|check>", Short: true},
+						},
+						Footer: "https://ci.example.com/builds/123", FooterIcon: ""},
+				},
+			},
+		},
+		"message file outside build directory": {
+			alert: Alert{
+				Type:        "default",
+				Message:     "Testing",
+				MessageFile: "../test_file",
+			},
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "Testing: demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						AuthorName: "Testing",
+						Title:      "demo/test #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						Fields: []slack.Field{
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
+						},
+						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
+				},
+			},
+		},
+		"strict message file": {
+			alert: Alert{
+				Type:        "default",
+				Message:     "Testing",
+				MessageFile: "test_file",
+				StrictFiles: true,
+			},
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "filecontents: demo/test/1 -- https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						AuthorName: "filecontents",
+						Title:      "demo/test #1",
+						TitleLink:  "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+						Fields: []slack.Field{
+							{Title: "Job", Value: "<https://ci.example.com/teams/main/pipelines/demo|demo>/<https://ci.example.com/teams/main/pipelines/demo/jobs/test|test>", Short: true},
+							{Title: "Build", Value: "<https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1|1>", Short: true},
+						},
+						Footer: "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1", FooterIcon: ""},
+				},
+			},
+		},
+		"error with strict missing file": {
+			alert: Alert{
+				Type:        "default",
+				Channel:     "testchannel",
+				ChannelFile: "missing file",
+				StrictFiles: true,
+			},
+			err: true,
+		},
+		"error with strict empty file": {
+			alert: Alert{
+				Type:        "default",
+				TextFile:    "empty_file",
+				StrictFiles: true,
+			},
+			err: true,
+		},
+		"error with strict file outside build directory": {
+			alert: Alert{
+				Type:        "default",
+				TextFile:    "../test_file",
+				StrictFiles: true,
+			},
+			err: true,
+		},
 	}
 
 	metadata := concourse.BuildMetadata{
@@ -347,21 +628,42 @@ func TestBuildMessage(t *testing.T) {
 		JobName:      "test",
 		BuildName:    "1",
 		URL:          "https://ci.example.com/teams/main/pipelines/demo/jobs/test/builds/1",
+		PipelineURL:  "https://ci.example.com/teams/main/pipelines/demo",
+		JobURL:       "https://ci.example.com/teams/main/pipelines/demo/jobs/test",
 	}
 
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
 			path := ""
-			if c.alert.MessageFile != "" || c.alert.ChannelFile != "" {
-				path = t.TempDir()
+			if c.alert.MessageFile != "" || c.alert.ChannelFile != "" || c.alert.TextFile != "" {
+				dir := t.TempDir()
+				path = filepath.Join(dir, "build")
 
-				if err := os.WriteFile(filepath.Join(path, "test_file"), []byte("filecontents"), 0666); err != nil {
+				if err := os.Mkdir(path, 0777); err != nil {
+					t.Fatal(err)
+				}
+				for _, p := range []string{dir, path} {
+					if err := os.WriteFile(filepath.Join(p, "test_file"), []byte("filecontents"), 0666); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := os.WriteFile(filepath.Join(path, "empty_file"), []byte{}, 0666); err != nil {
 					t.Fatal(err)
 				}
 			}
 
-			got := buildMessage(c.alert, metadata, path)
-			if !cmp.Equal(got, c.want) {
+			m := metadata
+			m.InstanceVars = c.instanceVars
+			if c.metadata != nil {
+				m = *c.metadata
+			}
+
+			got, err := buildMessage(c.alert, m, path, nil)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from buildMessage:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from buildMessage:\n\t(GOT): nil")
+			} else if !cmp.Equal(got, c.want) {
 				t.Fatalf("unexpected slack.Message value from buildSlackMessage:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
 			}
 		})
@@ -414,3 +716,43 @@ func TestPreviousBuildName(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSender(t *testing.T) {
+	source := concourse.Source{Sender: concourse.Sender{Username: "Concourse", IconEmoji: "concourse"}}
+
+	cases := map[string]struct {
+		input *concourse.OutRequest
+		want  *slack.Message
+	}{
+		"none": {
+			input: &concourse.OutRequest{},
+			want:  &slack.Message{},
+		},
+		"source": {
+			input: &concourse.OutRequest{Source: source},
+			want:  &slack.Message{Username: "Concourse", IconEmoji: ":concourse:"},
+		},
+		"params username": {
+			input: &concourse.OutRequest{Source: source, Params: concourse.OutParams{Username: "Deploy Bot"}},
+			want:  &slack.Message{Username: "Deploy Bot", IconEmoji: ":concourse:"},
+		},
+		"params icon url": {
+			input: &concourse.OutRequest{Source: source, Params: concourse.OutParams{IconURL: "https://example.com/deploy.png"}},
+			want:  &slack.Message{Username: "Concourse", IconURL: "https://example.com/deploy.png"},
+		},
+		"params icon emoji": {
+			input: &concourse.OutRequest{Source: source, Params: concourse.OutParams{Username: "Nightly Tests", IconEmoji: ":crescent_moon:"}},
+			want:  &slack.Message{Username: "Nightly Tests", IconEmoji: ":crescent_moon:"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := &slack.Message{}
+			setSender(got, c.input)
+			if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected slack.Message value from setSender:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+// redacted replaces the secrets of messages.
+const redacted = "[redacted]"
+
+// minRedactLength is the length of the shortest secret of the source that is
+// redacted. Shorter values, such as a password of "a", would redact common
+// words of every message.
+const minRedactLength = 6
+
+// builtinRedactPatterns match common secrets in messages.
+var builtinRedactPatterns = []*regexp.Regexp{
+	// AWS access key IDs and secret access keys
+	regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`(?i)(aws_secret_access_key|aws_session_token)\s*[=:]\s*(?P<secret>\S+)`),
+	// Slack tokens and webhook URLs
+	regexp.MustCompile(`\bxox[abposr]-[0-9A-Za-z-]{10,}`),
+	regexp.MustCompile(`https://hooks\.slack\.com/services/[0-9A-Za-z/]+`),
+	// JSON Web Tokens
+	regexp.MustCompile(`\beyJ[0-9A-Za-z_-]+\.eyJ[0-9A-Za-z_-]+\.[0-9A-Za-z_-]+`),
+	// Private key blocks
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// A redactor replaces secrets in the strings of a message.
+type redactor struct {
+	patterns []*regexp.Regexp
+	values   []string
+}
+
+// newRedactor returns a redactor for the built-in patterns, the patterns of
+// the source and the source's own secrets.
+func newRedactor(source concourse.Source) (*redactor, error) {
+	r := &redactor{patterns: builtinRedactPatterns}
+	for _, p := range source.RedactPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	for _, v := range []string{source.Password, source.URL, source.Token} {
+		if len(v) < minRedactLength {
+			continue
+		}
+		// Secrets may also appear escaped in mrkdwn.
+		r.values = append(r.values, v)
+		if e := escape(v); e != v {
+			r.values = append(r.values, e)
+		}
+	}
+	return r, nil
+}
+
+// redact returns s with all secrets replaced. If a pattern has a group
+// named "secret", only the group is replaced.
+func (r *redactor) redact(s string) string {
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, redacted)
+	}
+	for _, re := range r.patterns {
+		group := re.SubexpIndex("secret")
+		if group < 0 {
+			s = re.ReplaceAllLiteralString(s, redacted)
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+			start, end := loc[2*group], loc[2*group+1]
+			if start < 0 {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(redacted)
+			last = end
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// redactMessage replaces the secrets in all rendered strings of the message.
+func (r *redactor) redactMessage(m *slack.Message) {
+	m.Text = r.redact(m.Text)
+	for i := range m.Attachments {
+		a := &m.Attachments[i]
+		for _, s := range []*string{&a.Fallback, &a.AuthorName, &a.Title, &a.TitleLink, &a.Footer, &a.Text} {
+			*s = r.redact(*s)
+		}
+		for j := range a.Fields {
+			a.Fields[j].Title = r.redact(a.Fields[j].Title)
+			a.Fields[j].Value = r.redact(a.Fields[j].Value)
+		}
+	}
+	for i := range m.Replies {
+		r.redactMessage(&m.Replies[i])
+	}
+	// Only text snippets are redacted, as other files may be binary.
+	for i := range m.Files {
+		m.Files[i].Title = r.redact(m.Files[i].Title)
+		if m.Files[i].Snippet {
+			m.Files[i].Content = []byte(r.redact(string(m.Files[i].Content)))
+		}
+	}
+}
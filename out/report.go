@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+// defaultLongRunning is how long a build runs before it is reported as long
+// running.
+const defaultLongRunning = time.Hour
+
+// now returns the current time. It is replaced in tests.
+var now = time.Now
+
+// A reportPipeline is a pipeline and its jobs.
+type reportPipeline struct {
+	concourse.Pipeline
+	Jobs []concourse.Job
+}
+
+// teamReport returns a status report of the team's pipelines.
+func teamReport(input *concourse.OutRequest, alert Alert, m concourse.BuildMetadata) (*slack.Message, error) {
+	params := input.Params.Report
+	team := params.Team
+	if team == "" {
+		team = m.TeamName
+	}
+
+	c, err := concourse.NewClient(m.Host, team, input.Source.Username, input.Source.Password)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to Concourse: %w", err)
+	}
+
+	pipelines, err := c.Pipelines()
+	if err != nil {
+		return nil, fmt.Errorf("error requesting Concourse pipelines: %w", err)
+	}
+
+	var report []reportPipeline
+	for _, p := range pipelines {
+		if p.Archived || (len(params.Pipelines) > 0 && !concourse.MatchAny(params.Pipelines, p.Name)) || concourse.MatchAny(params.Exclude, p.Name) {
+			continue
+		}
+
+		jobs, err := c.Jobs(p.Name, p.InstanceVars)
+		if err != nil {
+			return nil, fmt.Errorf("error requesting Concourse jobs: %w", err)
+		}
+		report = append(report, reportPipeline{Pipeline: p, Jobs: jobs})
+	}
+
+	return buildReport(input, alert, m.Host, team, report), nil
+}
+
+// buildReport returns a message listing the failing, paused and long-running
+// jobs of the pipelines.
+func buildReport(input *concourse.OutRequest, alert Alert, host, team string, pipelines []reportPipeline) *slack.Message {
+	params := input.Params.Report
+	longRunning := time.Duration(params.LongRunning)
+	if longRunning == 0 {
+		longRunning = defaultLongRunning
+	}
+	maxAge := time.Duration(params.MaxAge)
+	l := alert.Locale
+
+	var failing, paused, running []string
+	for _, p := range pipelines {
+		pipelineURL := fmt.Sprintf("%s/teams/%s/pipelines/%s", host, url.PathEscape(team), url.PathEscape(p.Name))
+		query := p.InstanceVarsQuery()
+
+		if p.Paused {
+			paused = append(paused, fmt.Sprintf("• <%s%s|%s> %s", pipelineURL, query, escape(p.Name), l.text("(pipeline)")))
+		}
+
+		for _, j := range p.Jobs {
+			name := fmt.Sprintf("%s/%s", p.Name, j.Name)
+			if concourse.MatchAny(params.Exclude, name) {
+				continue
+			}
+			jobLink := fmt.Sprintf("<%s/jobs/%s%s|%s>", pipelineURL, url.PathEscape(j.Name), query, escape(name))
+
+			if j.Paused && !p.Paused {
+				paused = append(paused, fmt.Sprintf("• %s", jobLink))
+			}
+
+			if b := j.FinishedBuild; b != nil && slices.Contains([]string{"failed", "errored", "aborted"}, b.Status) {
+				if maxAge == 0 || now().Sub(time.Unix(int64(b.EndTime), 0)) <= maxAge {
+					failing = append(failing, fmt.Sprintf("• %s: %s (#%s)", jobLink, l.text(b.Status), escape(b.Name)))
+				}
+			}
+
+			if b := j.NextBuild; b != nil && b.Status == "started" && b.StartTime > 0 {
+				if d := now().Sub(time.Unix(int64(b.StartTime), 0)); d >= longRunning {
+					running = append(running, fmt.Sprintf("• %s: %s (#%s)", jobLink, l.format("running for %s", l.duration(d)), escape(b.Name)))
+				}
+			}
+		}
+	}
+
+	status := "success"
+	switch {
+	case len(failing) > 0:
+		status = "failed"
+	case len(paused) > 0 || len(running) > 0:
+		status = "started"
+	}
+	style := sourceTheme(input.Source.Theme).style(status)
+
+	color := input.Params.Color
+	if color == "" {
+		color = style.Color
+	}
+	message := alert.Message
+	if message == "" {
+		message = l.format("CI report: %s", team)
+	}
+	author := message
+	if style.Emoji != "" {
+		author = fmt.Sprintf("%s %s", style.Emoji, message)
+	}
+
+	var sections []string
+	for _, s := range []struct {
+		title string
+		lines []string
+	}{{l.text("Failing"), failing}, {l.text("Paused"), paused}, {l.text("Long running"), running}} {
+		if len(s.lines) > 0 {
+			sections = append(sections, fmt.Sprintf("*%s*\n%s", s.title, strings.Join(s.lines, "\n")))
+		}
+	}
+	text := strings.Join(sections, "\n\n")
+	if text == "" {
+		text = l.text("All jobs are healthy.")
+	}
+
+	attachment := slack.Attachment{
+		Fallback:   l.format("%s -- %d failing, %d paused, %d long running", message, len(failing), len(paused), len(running)),
+		AuthorName: author,
+		Color:      color,
+		Footer:     fmt.Sprintf("%s/teams/%s", host, url.PathEscape(team)),
+		FooterIcon: iconURL(input.Source.IconBaseURL, style.Icon),
+		Fields: []slack.Field{
+			{Title: l.text("Failing"), Value: fmt.Sprint(len(failing)), Short: true},
+			{Title: l.text("Paused"), Value: fmt.Sprint(len(paused)), Short: true},
+			{Title: l.text("Long running"), Value: fmt.Sprint(len(running)), Short: true},
+		},
+		Text: text,
+	}
+	return &slack.Message{Text: formatMentions(alert.Mentions), Attachments: []slack.Attachment{attachment}, Channel: alert.Channel}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildReport(t *testing.T) {
+	current := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	pipelines := []reportPipeline{
+		{
+			Pipeline: concourse.Pipeline{Name: "demo"},
+			Jobs: []concourse.Job{
+				{Name: "unit", FinishedBuild: &concourse.Build{Name: "3", Status: "failed", EndTime: int(current.Add(-time.Hour).Unix())}},
+				{Name: "old", FinishedBuild: &concourse.Build{Name: "1", Status: "failed", EndTime: int(current.Add(-72 * time.Hour).Unix())}},
+				{Name: "flaky", FinishedBuild: &concourse.Build{Name: "9", Status: "errored", EndTime: int(current.Unix())}},
+				{Name: "slow", NextBuild: &concourse.Build{Name: "4", Status: "started", StartTime: int(current.Add(-2 * time.Hour).Unix())}},
+				{Name: "deploy", Paused: true, FinishedBuild: &concourse.Build{Name: "2", Status: "succeeded"}},
+			},
+		},
+		{
+			Pipeline: concourse.Pipeline{Name: "release", Paused: true, InstanceVars: map[string]any{"version": "1.x"}},
+			Jobs: []concourse.Job{
+				{Name: "ship", Paused: true},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		input     *concourse.OutRequest
+		pipelines []reportPipeline
+		want      *slack.Message
+	}{
+		"report": {
+			input: &concourse.OutRequest{
+				Params: concourse.OutParams{Report: &concourse.ReportParams{
+					MaxAge:  concourse.Duration(24 * time.Hour),
+					Exclude: []string{"demo/flaky"},
+				}},
+			},
+			pipelines: pipelines,
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "CI report: main -- 1 failing, 2 paused, 1 long running",
+						Color:      "#d00000",
+						AuthorName: "CI report: main",
+						Fields: []slack.Field{
+							{Title: "Failing", Value: "1", Short: true},
+							{Title: "Paused", Value: "2", Short: true},
+							{Title: "Long running", Value: "1", Short: true},
+						},
+						Footer:     "https://ci.example.com/teams/main",
+						FooterIcon: "https://ci.concourse-ci.org/public/images/favicon-failed.png",
+						Text: "*Failing*\n" +
+							"• <https://ci.example.com/teams/main/pipelines/demo/jobs/unit|demo/unit>: failed (#3)\n\n" +
+							"*Paused*\n" +
+							"• <https://ci.example.com/teams/main/pipelines/demo/jobs/deploy|demo/deploy>\n" +
+							"• <https://ci.example.com/teams/main/pipelines/release?vars=%7B%22version%22%3A%221.x%22%7D|release> (pipeline)\n\n" +
+							"*Long running*\n" +
+							"• <https://ci.example.com/teams/main/pipelines/demo/jobs/slow|demo/slow>: running for 2h (#4)",
+					},
+				},
+			},
+		},
+		"healthy": {
+			input: &concourse.OutRequest{
+				Params: concourse.OutParams{Message: "State of CI", Report: &concourse.ReportParams{}},
+			},
+			pipelines: []reportPipeline{{Pipeline: concourse.Pipeline{Name: "demo"}}},
+			want: &slack.Message{
+				Attachments: []slack.Attachment{
+					{
+						Fallback:   "State of CI -- 0 failing, 0 paused, 0 long running",
+						Color:      "#32cd32",
+						AuthorName: "State of CI",
+						Fields: []slack.Field{
+							{Title: "Failing", Value: "0", Short: true},
+							{Title: "Paused", Value: "0", Short: true},
+							{Title: "Long running", Value: "0", Short: true},
+						},
+						Footer:     "https://ci.example.com/teams/main",
+						FooterIcon: "https://ci.concourse-ci.org/public/images/favicon-succeeded.png",
+						Text:       "All jobs are healthy.",
+					},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := buildReport(c.input, NewAlert(c.input, concourse.BuildMetadata{}, nil), "https://ci.example.com", "main", c.pipelines)
+			if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected slack.Message value from buildReport:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
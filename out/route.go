@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"path"
+	"slices"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+)
+
+// matchRoute returns the first route that matches the alert type and build.
+func matchRoute(routes []concourse.Route, atype string, m concourse.BuildMetadata) (concourse.Route, bool) {
+	for _, r := range routes {
+		if routeMatches(r, atype, m) {
+			return r, true
+		}
+	}
+	return concourse.Route{}, false
+}
+
+// routeMatches returns true if every condition of the route matches the
+// alert type and build. Names are matched by glob patterns.
+func routeMatches(r concourse.Route, atype string, m concourse.BuildMetadata) bool {
+	if len(r.AlertTypes) > 0 && !slices.Contains(r.AlertTypes, atype) {
+		return false
+	}
+	if len(r.Teams) > 0 && !concourse.MatchAny(r.Teams, m.TeamName) {
+		return false
+	}
+	if len(r.Pipelines) > 0 && !concourse.MatchAny(r.Pipelines, m.PipelineName) {
+		return false
+	}
+	if len(r.Jobs) > 0 && !concourse.MatchAny(r.Jobs, m.JobName) {
+		return false
+	}
+
+	for k, pattern := range r.InstanceVars {
+		v, ok := m.InstanceVars[k]
+		if !ok {
+			return false
+		}
+		s, ok := v.(string)
+		if !ok {
+			b, _ := json.Marshal(v)
+			s = string(b)
+		}
+		if ok, _ := path.Match(pattern, s); !ok {
+			return false
+		}
+	}
+	return true
+}
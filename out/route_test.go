@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+)
+
+func TestRouteMatches(t *testing.T) {
+	m := concourse.BuildMetadata{
+		TeamName:     "main",
+		PipelineName: "deploy",
+		JobName:      "deploy-prod",
+		InstanceVars: map[string]any{"env": "prod", "pr": 1234},
+	}
+
+	cases := map[string]struct {
+		route concourse.Route
+		atype string
+		want  bool
+	}{
+		"empty": {
+			want: true,
+		},
+		"alert type": {
+			route: concourse.Route{AlertTypes: []string{"failed", "broke"}},
+			atype: "broke",
+			want:  true,
+		},
+		"other alert type": {
+			route: concourse.Route{AlertTypes: []string{"failed", "broke"}},
+			atype: "success",
+		},
+		"names": {
+			route: concourse.Route{Teams: []string{"main"}, Pipelines: []string{"dep*"}, Jobs: []string{"*-prod"}},
+			want:  true,
+		},
+		"other job": {
+			route: concourse.Route{Teams: []string{"main"}, Jobs: []string{"*-staging"}},
+		},
+		"instance vars": {
+			route: concourse.Route{InstanceVars: map[string]string{"env": "prod", "pr": "12*"}},
+			want:  true,
+		},
+		"other instance vars": {
+			route: concourse.Route{InstanceVars: map[string]string{"env": "staging"}},
+		},
+		"missing instance var": {
+			route: concourse.Route{InstanceVars: map[string]string{"branch": "*"}},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := routeMatches(c.route, c.atype, m)
+			if got != c.want {
+				t.Fatalf("unexpected match from routeMatches:\n\t(GOT): %v\n\t(WNT): %v", got, c.want)
+			}
+		})
+	}
+}
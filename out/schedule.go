@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+)
+
+// parsePostAt parses post_at as an RFC 3339 time, or as a duration after now.
+func parsePostAt(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time or a duration", s)
+	}
+	return now.Add(d), nil
+}
+
+// schedule schedules the message to be posted at post_at. The ID of the
+// scheduled message is returned in the version, so that a later put can
+// cancel it with the files of its get, and in the metadata.
+func schedule(input *concourse.OutRequest, atype string, message *slack.Message) (*concourse.OutResponse, error) {
+	at, err := parsePostAt(input.Params.PostAt, now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid post_at: %w", err)
+	}
+	if !at.After(now()) {
+		return nil, fmt.Errorf("invalid post_at: %s is in the past", at.Format(time.RFC3339))
+	}
+
+	c := slack.NewClient(input.Source.Token, retryPolicy(input.Source.Retry))
+	channel, id, err := c.ScheduleMessage(message, at)
+	if err != nil {
+		return failed(input, atype, message.Channel, fmt.Errorf("error scheduling slack message: %w", err))
+	}
+
+	postAt := at.UTC().Format(time.RFC3339)
+	o := buildOut(atype, message.Channel, true)
+	o.Version = concourse.Version{"scheduled_message_id": id, "channel": channel, "post_at": postAt}
+	o.Metadata = append(o.Metadata,
+		concourse.Metadata{Name: "scheduled_message_id", Value: id},
+		concourse.Metadata{Name: "post_at", Value: postAt},
+	)
+	return o, nil
+}
+
+// cancelScheduled cancels the scheduled message of cancel_scheduled in the
+// channel of the alert.
+func cancelScheduled(input *concourse.OutRequest, alert Alert, path string) (*concourse.OutResponse, error) {
+	id, err := readFileParam("cancel_scheduled_file", path, input.Params.CancelScheduledFile, input.Params.CancelScheduled, alert.StrictFiles)
+	if err != nil {
+		return nil, err
+	}
+	channel, err := readFileParam("channel_file", path, alert.ChannelFile, alert.Channel, alert.StrictFiles)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, errors.New("no scheduled message to cancel")
+	}
+
+	if input.Params.DryRun || input.Source.DryRun {
+		fmt.Fprintf(os.Stderr, "dry run, not canceling scheduled message %s in %s\n", id, channel)
+		return buildOut("cancel", channel, false), nil
+	}
+
+	c := slack.NewClient(input.Source.Token, retryPolicy(input.Source.Retry))
+	if err := c.DeleteScheduledMessage(channel, id); err != nil {
+		return failed(input, "cancel", channel, fmt.Errorf("error canceling scheduled message %s: %w", id, err))
+	}
+
+	o := buildOut("cancel", channel, true)
+	o.Metadata = append(o.Metadata, concourse.Metadata{Name: "scheduled_message_id", Value: id})
+	return o, nil
+}
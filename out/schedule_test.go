@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/arbourd/concourse-slack-alert-resource/slack"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePostAt(t *testing.T) {
+	current := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		postAt string
+		want   time.Time
+		err    bool
+	}{
+		"rfc3339": {
+			postAt: "2026-03-02T17:30:00-05:00",
+			want:   time.Date(2026, 3, 2, 22, 30, 0, 0, time.UTC),
+		},
+		"duration": {
+			postAt: "2h30m",
+			want:   time.Date(2026, 3, 2, 17, 30, 0, 0, time.UTC),
+		},
+		"invalid": {
+			postAt: "5pm",
+			err:    true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := parsePostAt(c.postAt, current)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from parsePostAt:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from parsePostAt:\n\t(GOT): nil")
+			} else if !c.err && !got.Equal(c.want) {
+				t.Fatalf("unexpected time from parsePostAt:\n\t(GOT): %s\n\t(WNT): %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	current := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	var requests []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			slack.Message
+			PostAt             int64  `json:"post_at"`
+			ScheduledMessageID string `json:"scheduled_message_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch r.URL.Path {
+		case "/chat.scheduleMessage":
+			requests = append(requests, "schedule "+body.Channel+" "+time.Unix(body.PostAt, 0).UTC().Format(time.RFC3339))
+			w.Write([]byte(`{"ok":true,"channel":"C123","scheduled_message_id":"Q123"}`))
+		case "/chat.deleteScheduledMessage":
+			requests = append(requests, "delete "+body.Channel+" "+body.ScheduledMessageID)
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	url := slack.APIURL
+	slack.APIURL = s.URL
+	defer func() { slack.APIURL = url }()
+
+	for k, v := range map[string]string{
+		"ATC_EXTERNAL_URL":    "https://ci.example.com",
+		"BUILD_TEAM_NAME":     "main",
+		"BUILD_PIPELINE_NAME": "demo",
+		"BUILD_JOB_NAME":      "release",
+		"BUILD_NAME":          "7",
+	} {
+		t.Setenv(k, v)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "notify"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	for f, contents := range map[string]string{"notify/scheduled_message_id": "Q123\n", "notify/channel": "C123\n"} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := concourse.Source{Token: "xoxb-token", Channel: "releases"}
+	cases := map[string]struct {
+		params   concourse.OutParams
+		want     *concourse.OutResponse
+		requests []string
+		err      bool
+	}{
+		"post at duration": {
+			params: concourse.OutParams{AlertType: "success", PostAt: "3h"},
+			want: &concourse.OutResponse{
+				Version: concourse.Version{"scheduled_message_id": "Q123", "channel": "C123", "post_at": "2026-03-02T18:00:00Z"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "success"},
+					{Name: "channel", Value: "releases"},
+					{Name: "alerted", Value: "true"},
+					{Name: "scheduled_message_id", Value: "Q123"},
+					{Name: "post_at", Value: "2026-03-02T18:00:00Z"},
+				},
+			},
+			requests: []string{"schedule releases 2026-03-02T18:00:00Z"},
+		},
+		"post at time": {
+			params: concourse.OutParams{PostAt: "2026-03-02T17:00:00-05:00"},
+			want: &concourse.OutResponse{
+				Version: concourse.Version{"scheduled_message_id": "Q123", "channel": "C123", "post_at": "2026-03-02T22:00:00Z"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "default"},
+					{Name: "channel", Value: "releases"},
+					{Name: "alerted", Value: "true"},
+					{Name: "scheduled_message_id", Value: "Q123"},
+					{Name: "post_at", Value: "2026-03-02T22:00:00Z"},
+				},
+			},
+			requests: []string{"schedule releases 2026-03-02T22:00:00Z"},
+		},
+		"post at in the past": {
+			params: concourse.OutParams{PostAt: "2026-03-02T09:00:00-05:00"},
+			err:    true,
+		},
+		"cancel": {
+			params: concourse.OutParams{CancelScheduledFile: "notify/scheduled_message_id", ChannelFile: "notify/channel"},
+			want: &concourse.OutResponse{
+				Version: concourse.Version{"ver": "static"},
+				Metadata: []concourse.Metadata{
+					{Name: "type", Value: "cancel"},
+					{Name: "channel", Value: "C123"},
+					{Name: "alerted", Value: "true"},
+					{Name: "scheduled_message_id", Value: "Q123"},
+				},
+			},
+			requests: []string{"delete C123 Q123"},
+		},
+		"cancel without id": {
+			params: concourse.OutParams{CancelScheduledFile: "notify/missing"},
+			err:    true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			requests = nil
+			got, err := out(&concourse.OutRequest{Source: source, Params: c.params}, dir)
+			if err != nil && !c.err {
+				t.Fatalf("unexpected error from out:\n\t(ERR): %s", err)
+			} else if err == nil && c.err {
+				t.Fatalf("expected an error from out:\n\t(GOT): nil")
+			} else if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected concourse.OutResponse value from out:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+			if !cmp.Equal(requests, c.requests) {
+				t.Fatalf("unexpected requests to Slack:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", requests, c.requests, cmp.Diff(requests, c.requests))
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "strings"
+
+// textFormats are the ways the text of an alert can be formatted.
+var textFormats = []string{"mrkdwn", "plain", "code", "quote"}
+
+// formatText formats the text of an alert:
+//   - mrkdwn: as is, formatted by Slack
+//   - plain: escaped, and not formatted by Slack
+//   - code: escaped in a preformatted code block
+//   - quote: formatted by Slack in a block quote
+func formatText(text, format string) string {
+	switch format {
+	case "plain":
+		return escape(text)
+	case "code":
+		// Break up backticks that would end the code block early with
+		// zero-width spaces.
+		text = strings.ReplaceAll(escape(text), "```", "`\u200b`\u200b`")
+		return "```\n" + text + "\n```"
+	case "quote":
+		lines := strings.Split(text, "\n")
+		for i, l := range lines {
+			lines[i] = "> " + l
+		}
+		return strings.Join(lines, "\n")
+	default:
+		return text
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import "strings"
+
+// defaultIconBaseURL is where the icons of the built-in themes are hosted.
+const defaultIconBaseURL = "https://ci.concourse-ci.org/public/images"
+
+// A Style is the color, icon and emoji of an alert type.
+type Style struct {
+	Color string
+	Icon  string
+	Emoji string
+}
+
+// A Theme maps alert types to their Style. Alert types without a Style use
+// the Style of the default alert type.
+type Theme map[string]Style
+
+// style returns the Style of the alert type.
+func (t Theme) style(alertType string) Style {
+	if s, ok := t[alertType]; ok {
+		return s
+	}
+	return t["default"]
+}
+
+// sourceTheme returns the built-in theme by name, or the default theme.
+func sourceTheme(name string) Theme {
+	if t, ok := themes[name]; ok {
+		return t
+	}
+	return themes["default"]
+}
+
+// iconURL returns the URL of the icon under the base URL.
+func iconURL(base, icon string) string {
+	if icon == "" {
+		return ""
+	}
+	if base == "" {
+		base = defaultIconBaseURL
+	}
+	return strings.TrimSuffix(base, "/") + "/" + icon
+}
+
+// themes are the built-in themes by name.
+var themes = map[string]Theme{
+	"default": {
+		"default": {Color: "#35495c", Icon: "favicon-pending.png"},
+		"success": {Color: "#32cd32", Icon: "favicon-succeeded.png"},
+		"failed":  {Color: "#d00000", Icon: "favicon-failed.png"},
+		"started": {Color: "#f7cd42", Icon: "favicon-started.png"},
+		"aborted": {Color: "#8d4b32", Icon: "favicon-aborted.png"},
+		"fixed":   {Color: "#32cd32", Icon: "favicon-succeeded.png"},
+		"broke":   {Color: "#d00000", Icon: "favicon-failed.png"},
+		"errored": {Color: "#f5a623", Icon: "favicon-errored.png"},
+	},
+	// Colors from the Okabe-Ito palette, which is distinguishable with the
+	// common forms of color blindness.
+	"colorblind-friendly": {
+		"default": {Color: "#0072b2", Icon: "favicon-pending.png"},
+		"success": {Color: "#009e73", Icon: "favicon-succeeded.png"},
+		"failed":  {Color: "#d55e00", Icon: "favicon-failed.png"},
+		"started": {Color: "#f0e442", Icon: "favicon-started.png"},
+		"aborted": {Color: "#cc79a7", Icon: "favicon-aborted.png"},
+		"fixed":   {Color: "#009e73", Icon: "favicon-succeeded.png"},
+		"broke":   {Color: "#d55e00", Icon: "favicon-failed.png"},
+		"errored": {Color: "#e69f00", Icon: "favicon-errored.png"},
+	},
+	// Shades of gray, where darker is worse.
+	"monochrome": {
+		"default": {Color: "#555555", Icon: "favicon-pending.png"},
+		"success": {Color: "#bbbbbb", Icon: "favicon-succeeded.png"},
+		"failed":  {Color: "#111111", Icon: "favicon-failed.png"},
+		"started": {Color: "#888888", Icon: "favicon-started.png"},
+		"aborted": {Color: "#666666", Icon: "favicon-aborted.png"},
+		"fixed":   {Color: "#bbbbbb", Icon: "favicon-succeeded.png"},
+		"broke":   {Color: "#111111", Icon: "favicon-failed.png"},
+		"errored": {Color: "#333333", Icon: "favicon-errored.png"},
+	},
+	// Emoji instead of icons, which does not require any hosted images.
+	"emoji-only": {
+		"default": {Color: "#35495c", Emoji: ":large_blue_circle:"},
+		"success": {Color: "#32cd32", Emoji: ":white_check_mark:"},
+		"failed":  {Color: "#d00000", Emoji: ":x:"},
+		"started": {Color: "#f7cd42", Emoji: ":arrow_forward:"},
+		"aborted": {Color: "#8d4b32", Emoji: ":no_entry_sign:"},
+		"fixed":   {Color: "#32cd32", Emoji: ":tada:"},
+		"broke":   {Color: "#d00000", Emoji: ":boom:"},
+		"errored": {Color: "#f5a623", Emoji: ":warning:"},
+	},
+}
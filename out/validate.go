@@ -0,0 +1,399 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+)
+
+// colorRegexp matches hexadecimal colors like "#fff" or "#35495c".
+var colorRegexp = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// slackColors are the named colors accepted by Slack.
+var slackColors = []string{"good", "warning", "danger"}
+
+// decodeInput reads and validates the OutRequest. All validation errors are
+// reported at once.
+func decodeInput(r io.Reader) (*concourse.OutRequest, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var input *concourse.OutRequest
+	if err := json.Unmarshal(b, &input); err != nil {
+		return nil, err
+	}
+	if input == nil {
+		return nil, errors.New("request cannot be empty")
+	}
+
+	errs := unknownKeys("", b, reflect.TypeFor[concourse.OutRequest]())
+	errs = append(errs, validate(input)...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return input, nil
+}
+
+// needsChannel returns true if the put sends a message to a channel with
+// the Web API: if there is no webhook, or the params require a token.
+func needsChannel(input *concourse.OutRequest) bool {
+	p := input.Params
+	if input.Source.Token == "" {
+		return false
+	}
+	if p.EphemeralUser != "" || p.EphemeralUserFile != "" {
+		return true
+	}
+	if p.SkipChannel {
+		return false
+	}
+	return input.Source.URL == "" || p.AggregateKey != "" || (p.LongText != "" && p.LongText != "truncate") ||
+		len(p.Attachments) > 0 || p.PostAt != "" || p.CancelScheduled != "" || p.CancelScheduledFile != ""
+}
+
+// validate checks the values of the OutRequest.
+func validate(input *concourse.OutRequest) []error {
+	var errs []error
+
+	if input.Source.URL != "" || input.Source.Token == "" {
+		if err := validateURL(input.Source.URL); err != nil {
+			errs = append(errs, fmt.Errorf("invalid source.url: %w", err))
+		}
+	}
+	// Messages sent with the Web API are not sent to a default channel, as
+	// messages sent with a webhook are.
+	if needsChannel(input) {
+		alert := NewAlert(input, concourse.NewBuildMetadata(input.Source.ConcourseURL), nil)
+		if !alert.Disabled && alert.Channel == "" && alert.ChannelFile == "" {
+			errs = append(errs, errors.New("source.token requires source.channel, params.channel or the channels of a route"))
+		}
+	}
+	if input.Params.AggregateKey != "" && input.Source.Token == "" {
+		errs = append(errs, errors.New("params.aggregate_key requires source.token"))
+	}
+
+	if m := input.Params.LongText; m != "" {
+		switch {
+		case !slices.Contains(longTextModes, m):
+			errs = append(errs, fmt.Errorf("unknown params.long_text %q%s", m, suggest(m, longTextModes)))
+		case m != "truncate" && input.Source.Token == "":
+			errs = append(errs, fmt.Errorf("params.long_text %q requires source.token", m))
+		case m != "truncate" && input.Params.AggregateKey != "":
+			errs = append(errs, fmt.Errorf("params.long_text %q cannot be used with params.aggregate_key", m))
+		}
+	}
+	if f := input.Params.TextFormat; f != "" && !slices.Contains(textFormats, f) {
+		errs = append(errs, fmt.Errorf("unknown params.text_format %q%s", f, suggest(f, textFormats)))
+	}
+	if input.Params.TextLimit < 0 {
+		errs = append(errs, errors.New("invalid params.text_limit: cannot be negative"))
+	}
+
+	if len(input.Params.Attachments) > 0 {
+		if input.Source.Token == "" {
+			errs = append(errs, errors.New("params.attachments requires source.token"))
+		}
+		if input.Params.AggregateKey != "" {
+			errs = append(errs, errors.New("params.attachments cannot be used with params.aggregate_key"))
+		}
+	}
+	for _, p := range input.Params.Attachments {
+		if _, err := path.Match(p, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid params.attachments pattern %q: %w", p, err))
+		}
+	}
+	if in := input.Params.AttachmentsIn; in != "" && !slices.Contains(attachmentsIn, in) {
+		errs = append(errs, fmt.Errorf("unknown params.attachments_in %q%s", in, suggest(in, attachmentsIn)))
+	}
+	for _, p := range input.Params.JUnitReport {
+		if _, err := path.Match(p, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid params.junit_report pattern %q: %w", p, err))
+		}
+	}
+	if p := input.Params.PostAt; p != "" {
+		if _, err := parsePostAt(p, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("invalid params.post_at: %w", err))
+		}
+		if input.Source.Token == "" {
+			errs = append(errs, errors.New("params.post_at requires source.token"))
+		}
+		if input.Params.AggregateKey != "" {
+			errs = append(errs, errors.New("params.post_at cannot be used with params.aggregate_key"))
+		}
+		if len(input.Params.Attachments) > 0 {
+			errs = append(errs, errors.New("params.post_at cannot be used with params.attachments"))
+		}
+		if m := input.Params.LongText; m == "thread" || m == "upload" {
+			errs = append(errs, fmt.Errorf("params.post_at cannot be used with params.long_text %q", m))
+		}
+	}
+	if input.Params.CancelScheduled != "" || input.Params.CancelScheduledFile != "" {
+		if input.Source.Token == "" {
+			errs = append(errs, errors.New("params.cancel_scheduled requires source.token"))
+		}
+		if input.Params.PostAt != "" {
+			errs = append(errs, errors.New("params.cancel_scheduled cannot be used with params.post_at"))
+		}
+	}
+	direct := len(input.Params.DMUsers) > 0 || input.Params.DMUsersFile != "" || input.Params.EphemeralUser != "" || input.Params.EphemeralUserFile != ""
+	if direct {
+		if input.Source.Token == "" {
+			errs = append(errs, errors.New("params.dm_users and params.ephemeral_user require source.token"))
+		}
+		if input.Params.PostAt != "" {
+			errs = append(errs, errors.New("params.dm_users and params.ephemeral_user cannot be used with params.post_at"))
+		}
+	} else if input.Params.SkipChannel {
+		errs = append(errs, errors.New("params.skip_channel requires params.dm_users or params.ephemeral_user"))
+	}
+	if input.Params.MaxAttachments < 0 {
+		errs = append(errs, errors.New("invalid params.max_attachments: cannot be negative"))
+	}
+	if input.Params.MaxAttachmentSize < 0 {
+		errs = append(errs, errors.New("invalid params.max_attachment_size: cannot be negative"))
+	}
+	for _, s := range []struct {
+		prefix string
+		concourse.Sender
+	}{
+		{"source.sender.", input.Source.Sender},
+		{"params.", concourse.Sender{IconURL: input.Params.IconURL, IconEmoji: input.Params.IconEmoji}},
+	} {
+		if s.IconURL == "" {
+			continue
+		}
+		if err := validateURL(s.IconURL); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %sicon_url: %w", s.prefix, err))
+		}
+		if s.IconEmoji != "" {
+			errs = append(errs, fmt.Errorf("%sicon_url cannot be used with %sicon_emoji", s.prefix, s.prefix))
+		}
+	}
+	if input.Source.ConcourseURL != "" {
+		if err := validateURL(input.Source.ConcourseURL); err != nil {
+			errs = append(errs, fmt.Errorf("invalid source.concourse_url: %w", err))
+		}
+	}
+
+	if input.Source.IconBaseURL != "" {
+		if err := validateURL(input.Source.IconBaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("invalid source.icon_base_url: %w", err))
+		}
+	}
+
+	if t := input.Source.Theme; t != "" {
+		if _, ok := themes[t]; !ok {
+			names := slices.Sorted(maps.Keys(themes))
+			errs = append(errs, fmt.Errorf("unknown source.theme %q%s", t, suggest(t, names)))
+		}
+	}
+
+	if l := input.Source.Locale; l != "" {
+		if _, ok := builtinLocales[l]; !ok {
+			names := slices.Sorted(maps.Keys(builtinLocales))
+			errs = append(errs, fmt.Errorf("unknown source.locale %q%s", l, suggest(l, names)))
+		}
+	}
+
+	for _, p := range input.Source.RedactPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid source.redact_patterns %q: %w", p, err))
+		} else if re.MatchString("") {
+			// A pattern matching empty text redacts between every character.
+			errs = append(errs, fmt.Errorf("invalid source.redact_patterns %q: matches empty text", p))
+		}
+	}
+
+	types := slices.Sorted(maps.Keys(builtinAlerts))
+	for _, name := range slices.Sorted(maps.Keys(input.Source.AlertTypes)) {
+		if !slices.Contains(types, name) {
+			types = append(types, name)
+		}
+		if err := validateColor(input.Source.AlertTypes[name].Color); err != nil {
+			errs = append(errs, fmt.Errorf("invalid source.alert_types.%s.color %w", name, err))
+		}
+	}
+
+	if t := input.Params.AlertType; t != "" && !slices.Contains(types, t) {
+		errs = append(errs, fmt.Errorf("unknown params.alert_type %q%s", t, suggest(t, types)))
+	}
+
+	for i, r := range input.Source.Routes {
+		for _, t := range r.AlertTypes {
+			if !slices.Contains(types, t) {
+				errs = append(errs, fmt.Errorf("unknown source.routes[%d].alert_types %q%s", i, t, suggest(t, types)))
+			}
+		}
+		for _, p := range slices.Concat(r.Teams, r.Pipelines, r.Jobs, slices.Collect(maps.Values(r.InstanceVars))) {
+			if _, err := path.Match(p, ""); err != nil {
+				errs = append(errs, fmt.Errorf("invalid source.routes[%d] pattern %q: %w", i, p, err))
+			}
+		}
+	}
+
+	if err := validateColor(input.Params.Color); err != nil {
+		errs = append(errs, fmt.Errorf("invalid params.color %w", err))
+	}
+
+	if r := input.Params.Report; r != nil {
+		for _, p := range slices.Concat(r.Pipelines, r.Exclude) {
+			if _, err := path.Match(p, ""); err != nil {
+				errs = append(errs, fmt.Errorf("invalid params.report pattern %q: %w", p, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateColor checks that c is empty, a hexadecimal color or a named Slack
+// color.
+func validateColor(c string) error {
+	if c == "" || colorRegexp.MatchString(c) || slices.Contains(slackColors, c) {
+		return nil
+	}
+	return fmt.Errorf("%q: must be a hexadecimal color like \"#35495c\"", c)
+}
+
+// validateURL checks that s is an absolute HTTP(S) URL.
+func validateURL(s string) error {
+	if s == "" {
+		return errors.New("cannot be blank")
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q must use http or https", s)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q is missing a host", s)
+	}
+	return nil
+}
+
+// unknownKeys returns an error for every key in the JSON object that does not
+// match a field of t. Nested objects, maps and lists of objects are checked
+// recursively.
+func unknownKeys(prefix string, b []byte, t reflect.Type) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if reflect.PointerTo(t).Implements(reflect.TypeFor[json.Unmarshaler]()) {
+			return nil
+		}
+
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(b, &obj) != nil {
+			return nil
+		}
+
+		fields := map[string]reflect.Type{}
+		for f := range t.Fields() {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				fields[name] = f.Type
+			}
+		}
+		keys := slices.Sorted(maps.Keys(fields))
+
+		var errs []error
+		for _, k := range slices.Sorted(maps.Keys(obj)) {
+			ft, ok := fields[k]
+			if !ok {
+				errs = append(errs, fmt.Errorf("unknown key %q%s", prefix+k, suggest(k, keys)))
+				continue
+			}
+			errs = append(errs, unknownKeys(prefix+k+".", obj[k], ft)...)
+		}
+		return errs
+
+	case reflect.Map:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(b, &obj) != nil {
+			return nil
+		}
+
+		var errs []error
+		for _, k := range slices.Sorted(maps.Keys(obj)) {
+			errs = append(errs, unknownKeys(prefix+k+".", obj[k], t.Elem())...)
+		}
+		return errs
+
+	case reflect.Slice:
+		var list []json.RawMessage
+		if json.Unmarshal(b, &list) != nil {
+			return nil
+		}
+
+		var errs []error
+		for i, v := range list {
+			errs = append(errs, unknownKeys(fmt.Sprintf("%s%d.", prefix, i), v, t.Elem())...)
+		}
+		return errs
+	}
+
+	return nil
+}
+
+// suggest returns a did-you-mean hint for the closest known value to s, or an
+// empty string if nothing is close.
+func suggest(s string, known []string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(s, "-", "_"))
+
+	best, distance := "", 3
+	for _, k := range known {
+		if k == normalized {
+			return fmt.Sprintf(", did you mean %q?", k)
+		}
+		if d := levenshtein(normalized, k); d < distance {
+			best, distance = k, d
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arbourd/concourse-slack-alert-resource/concourse"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodeInput(t *testing.T) {
+	cases := map[string]struct {
+		input string
+		want  *concourse.OutRequest
+		errs  []string
+	}{
+		"valid": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","retry":{"max_attempts":2}},"params":{"alert_type":"success","color":"#fff"}}`,
+			want: &concourse.OutRequest{
+				Source: concourse.Source{URL: "https://hooks.slack.com/services/x", Retry: concourse.Retry{MaxAttempts: 2}},
+				Params: concourse.OutParams{AlertType: "success", Color: "#fff"},
+			},
+		},
+		"named color": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x"},"params":{"color":"danger"}}`,
+			want: &concourse.OutRequest{
+				Source: concourse.Source{URL: "https://hooks.slack.com/services/x"},
+				Params: concourse.OutParams{Color: "danger"},
+			},
+		},
+		"custom alert type": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","alert_types":{"deployed":{"color":"#0000ff"}}},"params":{"alert_type":"deployed"}}`,
+			want: &concourse.OutRequest{
+				Source: concourse.Source{
+					URL:        "https://hooks.slack.com/services/x",
+					AlertTypes: map[string]concourse.AlertType{"deployed": {Color: "#0000ff"}},
+				},
+				Params: concourse.OutParams{AlertType: "deployed"},
+			},
+		},
+		"invalid custom alert type": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","alert_types":{"deployed":{"colour":"blue"},"failed":{"color":"blue"}}}}`,
+			errs: []string{
+				`unknown key "source.alert_types.deployed.colour", did you mean "color"?`,
+				`invalid source.alert_types.failed.color "blue"`,
+			},
+		},
+		"unknown keys": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","retry":{"max_atempts":2}},"params":{"alert-type":"success","foo":"bar"}}`,
+			errs: []string{
+				`unknown key "params.alert-type", did you mean "alert_type"?`,
+				`unknown key "params.foo"`,
+				`unknown key "source.retry.max_atempts", did you mean "max_attempts"?`,
+			},
+		},
+		"invalid values": {
+			input: `{"source":{"url":"hooks.slack.com","concourse_url":"ftp://ci.example.com"},"params":{"alert_type":"sucess","color":"red"}}`,
+			errs: []string{
+				`invalid source.url: "hooks.slack.com" must use http or https`,
+				`invalid source.concourse_url: "ftp://ci.example.com" must use http or https`,
+				`unknown params.alert_type "sucess", did you mean "success"?`,
+				`invalid params.color "red"`,
+			},
+		},
+		"invalid theme": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","theme":"monochrom","icon_base_url":"/icons"}}`,
+			errs: []string{
+				`unknown source.theme "monochrom", did you mean "monochrome"?`,
+				`invalid source.icon_base_url: "/icons" must use http or https`,
+			},
+		},
+		"invalid post_at": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x"},"params":{"post_at":"5pm","aggregate_key":"tests","long_text":"thread"}}`,
+			errs: []string{
+				`params.aggregate_key requires source.token`,
+				`params.long_text "thread" requires source.token`,
+				`invalid params.post_at: "5pm" is not an RFC 3339 time or a duration`,
+				`params.post_at requires source.token`,
+				`params.post_at cannot be used with params.aggregate_key`,
+				`params.post_at cannot be used with params.long_text "thread"`,
+			},
+		},
+		"cancel_scheduled with post_at": {
+			input: `{"source":{"token":"xoxb-token","channel":"C123"},"params":{"post_at":"1h","cancel_scheduled":"Q123"}}`,
+			errs:  []string{`params.cancel_scheduled cannot be used with params.post_at`},
+		},
+		"dm_users without token": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x"},"params":{"dm_users":["dev@example.com"]}}`,
+			errs:  []string{`params.dm_users and params.ephemeral_user require source.token`},
+		},
+		"token without channel": {
+			input: `{"source":{"token":"xoxb-token"},"params":{"alert_type":"failed"}}`,
+			errs:  []string{"source.token requires source.channel, params.channel or the channels of a route"},
+		},
+		"token with route channel": {
+			input: `{"source":{"token":"xoxb-token","routes":[{"alert_types":["failed"],"channels":["#ci-alerts"]}]},"params":{"alert_type":"failed"}}`,
+			want: &concourse.OutRequest{
+				Source: concourse.Source{
+					Token:  "xoxb-token",
+					Routes: []concourse.Route{{AlertTypes: []string{"failed"}, Channels: []string{"#ci-alerts"}}},
+				},
+				Params: concourse.OutParams{AlertType: "failed"},
+			},
+		},
+		"token without matching route channel": {
+			input: `{"source":{"token":"xoxb-token","routes":[{"alert_types":["failed"],"channels":["#ci-alerts"]}]},"params":{"alert_type":"success"}}`,
+			errs:  []string{"source.token requires source.channel, params.channel or the channels of a route"},
+		},
+		"url and token without channel": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","token":"xoxb-token"},"params":{"aggregate_key":"tests","long_text":"upload"}}`,
+			errs: []string{
+				"source.token requires source.channel, params.channel or the channels of a route",
+				`params.long_text "upload" cannot be used with params.aggregate_key`,
+			},
+		},
+		"url and token with webhook channel": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","token":"xoxb-token"},"params":{"long_text":"truncate"}}`,
+			want: &concourse.OutRequest{
+				Source: concourse.Source{URL: "https://hooks.slack.com/services/x", Token: "xoxb-token"},
+				Params: concourse.OutParams{LongText: "truncate"},
+			},
+		},
+		"token with params channel": {
+			input: `{"source":{"token":"xoxb-token"},"params":{"channel":"#concourse"}}`,
+			want: &concourse.OutRequest{
+				Source: concourse.Source{Token: "xoxb-token"},
+				Params: concourse.OutParams{Channel: "#concourse"},
+			},
+		},
+		"skip_channel without users": {
+			input: `{"source":{"token":"xoxb-token"},"params":{"skip_channel":true}}`,
+			errs:  []string{`params.skip_channel requires params.dm_users or params.ephemeral_user`},
+		},
+		"invalid sender": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","sender":{"icon_url":"/bot.png"}},"params":{"icon_url":"https://example.com/bot.png","icon_emoji":":robot_face:"}}`,
+			errs: []string{
+				`invalid source.sender.icon_url: "/bot.png" must use http or https`,
+				`params.icon_url cannot be used with params.icon_emoji`,
+			},
+		},
+		"unknown locale": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","locale":"fre"}}`,
+			errs:  []string{`unknown source.locale "fre", did you mean "fr"?`},
+		},
+		"token without url": {
+			input: `{"source":{"token":"xoxb-token","channel":"C123"},"params":{"aggregate_key":"tests"}}`,
+			want: &concourse.OutRequest{
+				Source: concourse.Source{Token: "xoxb-token", Channel: "C123"},
+				Params: concourse.OutParams{AggregateKey: "tests"},
+			},
+		},
+		"aggregate without token": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x"},"params":{"aggregate_key":"tests"}}`,
+			errs:  []string{"params.aggregate_key requires source.token"},
+		},
+		"invalid redact pattern": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","redact_patterns":["token=(\\S+"]}}`,
+			errs:  []string{`invalid source.redact_patterns "token=(\\S+"`},
+		},
+		"empty redact patterns": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","redact_patterns":["","a*","ghp_\\w+"]}}`,
+			errs: []string{
+				`invalid source.redact_patterns "": matches empty text`,
+				`invalid source.redact_patterns "a*": matches empty text`,
+			},
+		},
+		"invalid long text": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x"},"params":{"long_text":"thread","text_limit":-1}}`,
+			errs: []string{
+				`params.long_text "thread" requires source.token`,
+				"invalid params.text_limit: cannot be negative",
+			},
+		},
+		"unknown text format": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x"},"params":{"text_format":"markdown"}}`,
+			errs:  []string{`unknown params.text_format "markdown", did you mean "mrkdwn"?`},
+		},
+		"unknown long text": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x"},"params":{"long_text":"truncated"}}`,
+			errs:  []string{`unknown params.long_text "truncated", did you mean "truncate"?`},
+		},
+		"invalid attachments": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x"},"params":{"attachments":["reports/[*.html"],"attachments_in":"thred","max_attachments":-1}}`,
+			errs: []string{
+				"params.attachments requires source.token",
+				`invalid params.attachments pattern "reports/[*.html"`,
+				`unknown params.attachments_in "thred", did you mean "thread"?`,
+				"invalid params.max_attachments: cannot be negative",
+			},
+		},
+		"invalid routes": {
+			input: `{"source":{"url":"https://hooks.slack.com/services/x","routes":[{"alert_types":["broken"],"jobs":["[deploy"]}]}}`,
+			errs: []string{
+				`unknown source.routes[0].alert_types "broken", did you mean "broke"?`,
+				`invalid source.routes[0] pattern "[deploy"`,
+			},
+		},
+		"blank url": {
+			input: `{"source":{}}`,
+			errs:  []string{"invalid source.url: cannot be blank"},
+		},
+		"empty": {
+			input: `null`,
+			errs:  []string{"request cannot be empty"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeInput(strings.NewReader(c.input))
+			if err != nil && len(c.errs) == 0 {
+				t.Fatalf("unexpected error from decodeInput:\n\t(ERR): %s", err)
+			} else if err == nil && len(c.errs) > 0 {
+				t.Fatalf("expected an error from decodeInput:\n\t(GOT): nil")
+			} else if err != nil {
+				for _, e := range c.errs {
+					if !strings.Contains(err.Error(), e) {
+						t.Fatalf("missing error from decodeInput:\n\t(GOT): %s\n\t(WNT): %s", err, e)
+					}
+				}
+				return
+			}
+
+			if !cmp.Equal(got, c.want) {
+				t.Fatalf("unexpected concourse.OutRequest from decodeInput:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", got, c.want, cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// APIURL is the base URL of the Slack Web API.
+var APIURL = "https://slack.com/api"
+
+// MessageMetadata is the metadata attached to a message
+// https://api.slack.com/metadata/using
+type MessageMetadata struct {
+	EventType    string         `json:"event_type"`
+	EventPayload map[string]any `json:"event_payload"`
+}
+
+// A Client sends requests to the Slack Web API with a bot token.
+type Client struct {
+	Token string
+	Retry Retry
+}
+
+// NewClient returns a Client that authenticates with the token.
+func NewClient(token string, r Retry) *Client {
+	return &Client{Token: token, Retry: r}
+}
+
+// apiResponse is the envelope of every Web API response.
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// PostMessage posts the message and returns the channel ID and timestamp of
+// the posted message.
+func (c *Client) PostMessage(m *Message) (string, string, error) {
+	var resp struct {
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+	}
+	err := c.post("chat.postMessage", m, &resp)
+	return resp.Channel, resp.TS, err
+}
+
+// Send posts the message, then its replies and files. Replies are posted
+// under the same name and icon as the message.
+func (c *Client) Send(m *Message) error {
+	channel, ts, err := c.PostMessage(m)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range m.Replies {
+		r.Channel = channel
+		r.ThreadTS = ts
+		r.Username, r.IconURL, r.IconEmoji = m.Username, m.IconURL, m.IconEmoji
+		if _, _, err := c.PostMessage(&r); err != nil {
+			return fmt.Errorf("error posting reply: %w", err)
+		}
+	}
+
+	for _, f := range m.Files {
+		threadTS := ts
+		if f.InChannel {
+			threadTS = ""
+		}
+		if err := c.UploadFile(channel, threadTS, f); err != nil {
+			return fmt.Errorf("error uploading %s: %w", f.Filename, err)
+		}
+	}
+	return nil
+}
+
+// UploadFile uploads the file to the channel ID, in the thread of the
+// message with the timestamp if it is set.
+// https://api.slack.com/messaging/files#upload
+func (c *Client) UploadFile(channel, ts string, f File) error {
+	var upload struct {
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	form := url.Values{}
+	form.Set("filename", f.Filename)
+	form.Set("length", fmt.Sprint(len(f.Content)))
+	if f.Snippet {
+		form.Set("snippet_type", "text")
+	}
+	if err := c.postForm("files.getUploadURLExternal", form, &upload); err != nil {
+		return err
+	}
+
+	err := backoff.Retry(
+		func() error {
+			r, err := http.Post(upload.UploadURL, "application/octet-stream", bytes.NewReader(f.Content))
+			if err != nil {
+				return err
+			}
+			defer r.Body.Close()
+
+			if r.StatusCode > 399 {
+				return fmt.Errorf("unexpected response status code: %d", r.StatusCode)
+			}
+			return nil
+		},
+		c.Retry.backOff(),
+	)
+	if err != nil {
+		return err
+	}
+
+	files, err := json.Marshal([]map[string]string{{"id": upload.FileID, "title": f.Title}})
+	if err != nil {
+		return err
+	}
+	form = url.Values{}
+	form.Set("files", string(files))
+	form.Set("channel_id", channel)
+	if ts != "" {
+		form.Set("thread_ts", ts)
+	}
+	return c.postForm("files.completeUploadExternal", form, nil)
+}
+
+// UpdateMessage replaces the message identified by the channel ID and
+// timestamp.
+func (c *Client) UpdateMessage(channel, ts string, m *Message) error {
+	u := *m
+	u.Channel = channel
+	u.TS = ts
+	return c.post("chat.update", &u, nil)
+}
+
+// DeleteMessage deletes the message identified by the channel ID and
+// timestamp.
+func (c *Client) DeleteMessage(channel, ts string) error {
+	return c.post("chat.delete", map[string]string{"channel": channel, "ts": ts}, nil)
+}
+
+// ScheduleMessage schedules the message to be posted at the time, and
+// returns the channel ID and the ID of the scheduled message.
+// https://api.slack.com/methods/chat.scheduleMessage
+func (c *Client) ScheduleMessage(m *Message, at time.Time) (string, string, error) {
+	body := struct {
+		*Message
+		PostAt int64 `json:"post_at"`
+	}{m, at.Unix()}
+
+	var resp struct {
+		Channel            string `json:"channel"`
+		ScheduledMessageID string `json:"scheduled_message_id"`
+	}
+	err := c.post("chat.scheduleMessage", body, &resp)
+	return resp.Channel, resp.ScheduledMessageID, err
+}
+
+// DeleteScheduledMessage cancels the scheduled message identified by the
+// channel ID and scheduled message ID.
+// https://api.slack.com/methods/chat.deleteScheduledMessage
+func (c *Client) DeleteScheduledMessage(channel, id string) error {
+	return c.post("chat.deleteScheduledMessage", map[string]string{"channel": channel, "scheduled_message_id": id}, nil)
+}
+
+// PostEphemeral posts the message to its channel, visible only to the user
+// ID. Replies and files are not posted.
+// https://api.slack.com/methods/chat.postEphemeral
+func (c *Client) PostEphemeral(user string, m *Message) error {
+	body := struct {
+		*Message
+		User string `json:"user"`
+	}{m, user}
+	return c.post("chat.postEphemeral", body, nil)
+}
+
+// OpenConversation opens a direct message with the user ID, and returns its
+// channel ID.
+// https://api.slack.com/methods/conversations.open
+func (c *Client) OpenConversation(user string) (string, error) {
+	var resp struct {
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}
+	err := c.post("conversations.open", map[string]string{"users": user}, &resp)
+	return resp.Channel.ID, err
+}
+
+// LookupUserByEmail returns the ID of the user with the email address.
+// https://api.slack.com/methods/users.lookupByEmail
+func (c *Client) LookupUserByEmail(email string) (string, error) {
+	var resp struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	err := c.postForm("users.lookupByEmail", url.Values{"email": {email}}, &resp)
+	return resp.User.ID, err
+}
+
+// channelIDRegexp matches the IDs of channels, which are not lower case like
+// channel names.
+var channelIDRegexp = regexp.MustCompile(`^[CGD][0-9A-Z]{8,}$`)
+
+// ChannelID returns the ID of the channel, which is a channel ID or a name
+// like "#concourse". Names are looked up in the channels of the workspace.
+// https://api.slack.com/methods/conversations.list
+func (c *Client) ChannelID(channel string) (string, error) {
+	name := strings.TrimPrefix(channel, "#")
+	if channelIDRegexp.MatchString(name) {
+		return name, nil
+	}
+
+	q := url.Values{}
+	q.Set("types", "public_channel,private_channel")
+	q.Set("exclude_archived", "true")
+	q.Set("limit", "1000")
+	for {
+		var resp struct {
+			Channels []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"channels"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		err := c.do(func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, APIURL+"/conversations.list?"+q.Encode(), nil)
+		}, &resp)
+		if err != nil {
+			return "", err
+		}
+
+		for _, ch := range resp.Channels {
+			if ch.Name == name {
+				return ch.ID, nil
+			}
+		}
+		if resp.ResponseMetadata.NextCursor == "" {
+			return "", fmt.Errorf("channel %s not found", channel)
+		}
+		q.Set("cursor", resp.ResponseMetadata.NextCursor)
+	}
+}
+
+// History returns up to limit of the most recent messages of the channel
+// posted after oldest, including their metadata. Messages are ordered from
+// newest to oldest.
+func (c *Client) History(channel, oldest string, limit int) ([]Message, error) {
+	q := url.Values{}
+	q.Set("channel", channel)
+	q.Set("include_all_metadata", "true")
+	q.Set("inclusive", "true")
+	q.Set("limit", fmt.Sprint(limit))
+	if oldest != "" {
+		q.Set("oldest", oldest)
+	}
+
+	var resp struct {
+		Messages []Message `json:"messages"`
+	}
+	err := c.do(func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, APIURL+"/conversations.history?"+q.Encode(), nil)
+	}, &resp)
+	return resp.Messages, err
+}
+
+// post sends the body as JSON to the Web API method and decodes the response
+// into v.
+func (c *Client) post(method string, body any, v any) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	return c.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, APIURL+"/"+method, bytes.NewReader(buf))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		return req, nil
+	}, v)
+}
+
+// postForm sends the form to the Web API method and decodes the response
+// into v.
+func (c *Client) postForm(method string, form url.Values, v any) error {
+	return c.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, APIURL+"/"+method, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}, v)
+}
+
+// do sends the request with the retry policy of the client. Errors reported
+// by the Web API are not retried, except for rate limiting.
+func (c *Client) do(newRequest func() (*http.Request, error), v any) error {
+	return backoff.Retry(
+		func() error {
+			req, err := newRequest()
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+
+			r, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			defer r.Body.Close()
+
+			if r.StatusCode > 399 {
+				return fmt.Errorf("unexpected response status code: %d", r.StatusCode)
+			}
+
+			var b json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+				return err
+			}
+			var resp apiResponse
+			if err := json.Unmarshal(b, &resp); err != nil {
+				return backoff.Permanent(err)
+			}
+			if !resp.OK {
+				err := errors.New(resp.Error)
+				if resp.Error == "ratelimited" {
+					return err
+				}
+				return backoff.Permanent(err)
+			}
+
+			if v == nil {
+				return nil
+			}
+			return backoff.Permanent(json.Unmarshal(b, v))
+		},
+		c.Retry.backOff(),
+	)
+}
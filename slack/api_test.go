@@ -0,0 +1,288 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPostMessage(t *testing.T) {
+	cases := map[string]struct {
+		responses []string
+		wantTS    string
+		wantErr   bool
+	}{
+		"ok": {
+			responses: []string{`{"ok":true,"channel":"C123","ts":"1.000000"}`},
+			wantTS:    "1.000000",
+		},
+		"api error": {
+			responses: []string{`{"ok":false,"error":"channel_not_found"}`, `{"ok":true,"channel":"C123","ts":"1.000000"}`},
+			wantErr:   true,
+		},
+		"rate limited": {
+			responses: []string{`{"ok":false,"error":"ratelimited"}`, `{"ok":true,"channel":"C123","ts":"2.000000"}`},
+			wantTS:    "2.000000",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			responses := c.responses
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer xoxb-token" {
+					http.Error(w, "", http.StatusUnauthorized)
+					return
+				}
+				var m Message
+				if err := json.NewDecoder(r.Body).Decode(&m); err != nil || m.Channel != "concourse" {
+					http.Error(w, "", http.StatusBadRequest)
+					return
+				}
+				w.Write([]byte(responses[0]))
+				responses = responses[1:]
+			}))
+			defer s.Close()
+
+			url := APIURL
+			APIURL = s.URL
+			defer func() { APIURL = url }()
+
+			client := NewClient("xoxb-token", Retry{InitialInterval: time.Millisecond, MaxElapsedTime: time.Second})
+			channel, ts, err := client.PostMessage(&Message{Channel: "concourse"})
+			if err != nil && !c.wantErr {
+				t.Fatalf("unexpected error from PostMessage:\n\t(ERR): %s", err)
+			} else if err == nil && c.wantErr {
+				t.Fatalf("expected an error from PostMessage:\n\t(GOT): nil")
+			}
+			if c.wantErr {
+				return
+			}
+
+			if channel != "C123" || ts != c.wantTS {
+				t.Fatalf("unexpected message from PostMessage:\n\t(GOT): %s %s\n\t(WNT): C123 %s", channel, ts, c.wantTS)
+			}
+		})
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	var calls []string
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chat.postMessage":
+			var m Message
+			json.NewDecoder(r.Body).Decode(&m)
+			calls = append(calls, "post "+m.Channel+" "+m.ThreadTS+" "+m.Text+" as "+m.Username+" "+m.IconEmoji)
+			w.Write([]byte(`{"ok":true,"channel":"C123","ts":"1.000000"}`))
+		case "/files.getUploadURLExternal":
+			r.ParseForm()
+			calls = append(calls, "upload "+r.Form.Get("filename")+" "+r.Form.Get("length"))
+			w.Write([]byte(`{"ok":true,"upload_url":"` + s.URL + `/upload","file_id":"F123"}`))
+		case "/upload":
+			b, _ := io.ReadAll(r.Body)
+			calls = append(calls, "content "+string(b))
+		case "/files.completeUploadExternal":
+			r.ParseForm()
+			calls = append(calls, "complete "+r.Form.Get("files")+" "+r.Form.Get("channel_id")+" "+r.Form.Get("thread_ts"))
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	url := APIURL
+	APIURL = s.URL
+	defer func() { APIURL = url }()
+
+	m := &Message{
+		Channel:   "concourse",
+		Text:      "main",
+		Username:  "Deploy Bot",
+		IconEmoji: ":rocket:",
+		Replies:   []Message{{Text: "reply"}},
+		Files: []File{
+			{Filename: "text.txt", Title: "Full text", Content: []byte("full"), Snippet: true},
+			{Filename: "coverage.html", Title: "coverage.html", Content: []byte("<html>"), InChannel: true},
+		},
+	}
+	err := NewClient("xoxb-token", Retry{MaxElapsedTime: time.Second}).Send(m)
+	if err != nil {
+		t.Fatalf("unexpected error from Send:\n\t(ERR): %s", err)
+	}
+
+	want := []string{
+		"post concourse  main as Deploy Bot :rocket:",
+		"post C123 1.000000 reply as Deploy Bot :rocket:",
+		"upload text.txt 4",
+		"content full",
+		`complete [{"id":"F123","title":"Full text"}] C123 1.000000`,
+		"upload coverage.html 6",
+		"content <html>",
+		`complete [{"id":"F123","title":"coverage.html"}] C123 `,
+	}
+	if !cmp.Equal(calls, want) {
+		t.Fatalf("unexpected requests from Send:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", calls, want, cmp.Diff(calls, want))
+	}
+}
+
+func TestScheduleMessage(t *testing.T) {
+	var calls []map[string]any
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		body["method"] = r.URL.Path
+		calls = append(calls, body)
+		w.Write([]byte(`{"ok":true,"channel":"C123","scheduled_message_id":"Q123"}`))
+	}))
+	defer s.Close()
+
+	url := APIURL
+	APIURL = s.URL
+	defer func() { APIURL = url }()
+
+	c := NewClient("xoxb-token", Retry{MaxElapsedTime: time.Second})
+	channel, id, err := c.ScheduleMessage(&Message{Channel: "concourse", Text: "release"}, time.Unix(1767261600, 0))
+	if err != nil {
+		t.Fatalf("unexpected error from ScheduleMessage:\n\t(ERR): %s", err)
+	}
+	if channel != "C123" || id != "Q123" {
+		t.Fatalf("unexpected IDs from ScheduleMessage:\n\t(GOT): %s %s\n\t(WNT): C123 Q123", channel, id)
+	}
+	if err := c.DeleteScheduledMessage(channel, id); err != nil {
+		t.Fatalf("unexpected error from DeleteScheduledMessage:\n\t(ERR): %s", err)
+	}
+
+	want := []map[string]any{
+		{"method": "/chat.scheduleMessage", "channel": "concourse", "text": "release", "attachments": nil, "post_at": float64(1767261600)},
+		{"method": "/chat.deleteScheduledMessage", "channel": "C123", "scheduled_message_id": "Q123"},
+	}
+	if !cmp.Equal(calls, want) {
+		t.Fatalf("unexpected requests from ScheduleMessage:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", calls, want, cmp.Diff(calls, want))
+	}
+}
+
+func TestDirectMessage(t *testing.T) {
+	var calls []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users.lookupByEmail":
+			r.ParseForm()
+			calls = append(calls, "lookup "+r.Form.Get("email"))
+			if r.Form.Get("email") != "dev@example.com" {
+				w.Write([]byte(`{"ok":false,"error":"users_not_found"}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true,"user":{"id":"U123"}}`))
+		case "/conversations.open":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			calls = append(calls, "open "+body["users"])
+			w.Write([]byte(`{"ok":true,"channel":{"id":"D123"}}`))
+		case "/chat.postEphemeral":
+			var body struct {
+				Message
+				User string `json:"user"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			calls = append(calls, "ephemeral "+body.Channel+" "+body.User+" "+body.Text)
+			w.Write([]byte(`{"ok":true,"message_ts":"1.000000"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	url := APIURL
+	APIURL = s.URL
+	defer func() { APIURL = url }()
+
+	c := NewClient("xoxb-token", Retry{MaxElapsedTime: time.Second})
+	user, err := c.LookupUserByEmail("dev@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error from LookupUserByEmail:\n\t(ERR): %s", err)
+	}
+	if _, err := c.LookupUserByEmail("other@example.com"); err == nil {
+		t.Fatalf("expected an error from LookupUserByEmail:\n\t(GOT): nil")
+	}
+	channel, err := c.OpenConversation(user)
+	if err != nil {
+		t.Fatalf("unexpected error from OpenConversation:\n\t(ERR): %s", err)
+	}
+	if err := c.PostEphemeral(user, &Message{Channel: "concourse", Text: "failed"}); err != nil {
+		t.Fatalf("unexpected error from PostEphemeral:\n\t(ERR): %s", err)
+	}
+
+	if channel != "D123" {
+		t.Fatalf("unexpected channel from OpenConversation:\n\t(GOT): %s\n\t(WNT): D123", channel)
+	}
+	want := []string{
+		"lookup dev@example.com",
+		"lookup other@example.com",
+		"open U123",
+		"ephemeral concourse U123 failed",
+	}
+	if !cmp.Equal(calls, want) {
+		t.Fatalf("unexpected requests to Slack:\n\t(GOT): %#v\n\t(WNT): %#v\n\t(DIFF): %v", calls, want, cmp.Diff(calls, want))
+	}
+}
+
+func TestChannelID(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			w.Write([]byte(`{"ok":true,"channels":[{"id":"C111","name":"general"}],"response_metadata":{"next_cursor":"page2"}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"channels":[{"id":"C222","name":"concourse"}],"response_metadata":{"next_cursor":""}}`))
+	}))
+	defer s.Close()
+
+	url := APIURL
+	APIURL = s.URL
+	defer func() { APIURL = url }()
+
+	cases := map[string]struct {
+		channel string
+		want    string
+		wantErr bool
+	}{
+		"id": {
+			channel: "C0123ABCD",
+			want:    "C0123ABCD",
+		},
+		"name": {
+			channel: "#general",
+			want:    "C111",
+		},
+		"name on next page": {
+			channel: "concourse",
+			want:    "C222",
+		},
+		"not found": {
+			channel: "#missing",
+			wantErr: true,
+		},
+	}
+
+	c := NewClient("xoxb-token", Retry{MaxElapsedTime: time.Second})
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.ChannelID(tc.channel)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error from ChannelID:\n\t(ERR): %s", err)
+			} else if err == nil && tc.wantErr {
+				t.Fatalf("expected an error from ChannelID:\n\t(GOT): nil")
+			}
+			if got != tc.want {
+				t.Fatalf("unexpected channel from ChannelID:\n\t(GOT): %s\n\t(WNT): %s", got, tc.want)
+			}
+		})
+	}
+}
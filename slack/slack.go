@@ -13,20 +13,48 @@ import (
 // Message represents a Slack API message
 // https://api.slack.com/docs/messages
 type Message struct {
-	Attachments []Attachment `json:"attachments"`
-	Channel     string       `json:"channel,omitempty"`
+	Text        string           `json:"text,omitempty"`
+	Attachments []Attachment     `json:"attachments"`
+	Channel     string           `json:"channel,omitempty"`
+	TS          string           `json:"ts,omitempty"`
+	ThreadTS    string           `json:"thread_ts,omitempty"`
+	Metadata    *MessageMetadata `json:"metadata,omitempty"`
+	Username    string           `json:"username,omitempty"`
+	IconURL     string           `json:"icon_url,omitempty"`
+	IconEmoji   string           `json:"icon_emoji,omitempty"`
+
+	// Replies are posted in the thread of the message, and Files are
+	// uploaded after it. Both require the Web API.
+	Replies []Message `json:"-"`
+	Files   []File    `json:"-"`
+}
+
+// File is a file uploaded with a message
+// https://api.slack.com/messaging/files
+type File struct {
+	Filename string
+	Title    string
+	Content  []byte
+	// Snippet uploads the file as a text snippet.
+	Snippet bool
+	// InChannel shares the file in the channel instead of the message's
+	// thread.
+	InChannel bool
 }
 
 // Attachment represents a Slack API message attachment
 // https://api.slack.com/docs/message-attachments
 type Attachment struct {
-	Fallback   string  `json:"fallback"`
-	Color      string  `json:"color"`
-	AuthorName string  `json:"author_name"`
-	Fields     []Field `json:"fields"`
-	Footer     string  `json:"footer"`
-	FooterIcon string  `json:"footer_icon"`
-	Text       string  `json:"text"`
+	Fallback   string   `json:"fallback"`
+	Color      string   `json:"color"`
+	AuthorName string   `json:"author_name"`
+	Title      string   `json:"title,omitempty"`
+	TitleLink  string   `json:"title_link,omitempty"`
+	Fields     []Field  `json:"fields"`
+	Footer     string   `json:"footer"`
+	FooterIcon string   `json:"footer_icon"`
+	Text       string   `json:"text"`
+	MrkdwnIn   []string `json:"mrkdwn_in,omitempty"`
 }
 
 // Field represents a Slack API message attachment's fields
@@ -37,8 +65,35 @@ type Field struct {
 	Short bool   `json:"short"`
 }
 
+// Retry is the retry policy for sending a message. Zero values use the
+// defaults of the backoff package.
+type Retry struct {
+	MaxElapsedTime  time.Duration
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxAttempts     int
+}
+
+// backOff returns the exponential backoff for the retry policy.
+func (r Retry) backOff() backoff.BackOff {
+	opts := []backoff.ExponentialBackOffOpts{backoff.WithMaxElapsedTime(r.MaxElapsedTime)}
+	if r.InitialInterval > 0 {
+		opts = append(opts, backoff.WithInitialInterval(r.InitialInterval))
+	}
+	if r.MaxInterval > 0 {
+		opts = append(opts, backoff.WithMaxInterval(r.MaxInterval))
+	}
+
+	var b backoff.BackOff = backoff.NewExponentialBackOff(opts...)
+	// The first attempt is not a retry.
+	if r.MaxAttempts > 0 {
+		b = backoff.WithMaxRetries(b, uint64(r.MaxAttempts-1))
+	}
+	return b
+}
+
 // Send sends the message to the webhook URL.
-func Send(url string, m *Message, maxRetryTime time.Duration) error {
+func Send(url string, m *Message, r Retry) error {
 	buf, err := json.Marshal(m)
 	if err != nil {
 		return err
@@ -57,7 +112,7 @@ func Send(url string, m *Message, maxRetryTime time.Duration) error {
 			}
 			return nil
 		},
-		backoff.NewExponentialBackOff(backoff.WithMaxElapsedTime(maxRetryTime)),
+		r.backOff(),
 	)
 
 	if err != nil {
@@ -11,6 +11,7 @@ func TestSend(t *testing.T) {
 	cases := map[string]struct {
 		message *Message
 		backoff uint8
+		retry   Retry
 		wantErr bool
 	}{
 		"ok": {
@@ -26,6 +27,17 @@ func TestSend(t *testing.T) {
 			backoff: 255,
 			wantErr: true,
 		},
+		"max attempts ok": {
+			message: &Message{Channel: "concourse"},
+			backoff: 2,
+			retry:   Retry{InitialInterval: time.Millisecond, MaxAttempts: 3},
+		},
+		"max attempts fail": {
+			message: &Message{Channel: "concourse"},
+			backoff: 3,
+			retry:   Retry{InitialInterval: time.Millisecond, MaxAttempts: 3},
+			wantErr: true,
+		},
 	}
 
 	for name, c := range cases {
@@ -40,7 +52,12 @@ func TestSend(t *testing.T) {
 			}))
 			defer s.Close()
 
-			err := Send(s.URL, c.message, 2*time.Second)
+			retry := c.retry
+			if retry.MaxElapsedTime == 0 {
+				retry.MaxElapsedTime = 2 * time.Second
+			}
+
+			err := Send(s.URL, c.message, retry)
 			if err != nil && !c.wantErr {
 				t.Fatalf("unexpected error from Send:\n\t(ERR): %s", err)
 			} else if err == nil && c.wantErr {